@@ -0,0 +1,155 @@
+package katsuragi
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	Url "net/url"
+	"sync"
+	"time"
+)
+
+const (
+    defaultCheckLinksConcurrency        = 10
+    defaultCheckLinksPerHostConcurrency = 2
+)
+
+// CheckLinks extracts the links of the given URL (see GetLinkDetails) and probes every unique link with HEAD,
+// falling back to GET, under bounded concurrency. The status, final URL after redirects, latency and error
+// are reported for each link, in document order.
+func (f *Fetcher) CheckLinks(url string, props CheckLinksProps) ([]LinkStatus, error) {
+    linksProps := GetLinksProps{
+        Url:      url,
+        Category: props.Category,
+        Kinds:    []LinkKind{LinkKindWeb},
+        Filters:  props.Filters,
+        Dedupe:   LinkDedupeExact,
+    }
+    if err := normalizeLinksProps(&linksProps, "CheckLinks"); err != nil {
+        return nil, err
+    }
+    if props.Concurrency <= 0 {
+        props.Concurrency = defaultCheckLinksConcurrency
+    }
+    if props.PerHostConcurrency <= 0 {
+        props.PerHostConcurrency = defaultCheckLinksPerHostConcurrency
+    }
+
+    doc, err := retrieveHTML(url, f)
+    if err != nil {
+        return nil, err
+    }
+    links := extractLinkDetails(doc, linksProps)
+    if len(links) == 0 {
+        return nil, fmt.Errorf("CheckLinks failed to find any links in HTML")
+    }
+
+    client := f.newHTTPClient()
+    results := make([]LinkStatus, len(links))
+    limiter := newHostLimiter(props.Concurrency, props.PerHostConcurrency)
+
+    var wg sync.WaitGroup
+    for i, link := range links {
+        wg.Add(1)
+        go func(i int, link Link) {
+            defer wg.Done()
+            host := ""
+            if parsedUrl, err := Url.Parse(link.URL); err == nil {
+                host = parsedUrl.Host
+            }
+            release := limiter.acquire(host)
+            defer release()
+            results[i] = probeLink(client, link)
+        }(i, link)
+    }
+    wg.Wait()
+
+    return results, nil
+}
+
+// probeLink requests the link with HEAD, falling back to GET when HEAD fails or is not supported
+func probeLink(client *http.Client, link Link) LinkStatus {
+    status := LinkStatus{Link: link}
+    start := time.Now()
+    for _, method := range []string{"HEAD", "GET"} {
+        // the fields of a previous attempt are cleared, so the status never mixes two requests
+        req, err := http.NewRequest(method, link.URL, nil)
+        if err != nil {
+            status = LinkStatus{Link: link, Err: err}
+            break
+        }
+        httpResp, err := client.Do(req)
+        if err != nil {
+            status = LinkStatus{Link: link, Err: err}
+            continue
+        }
+        // only the status is needed, the body of GET responses is drained to reuse the connection
+        io.Copy(io.Discard, io.LimitReader(httpResp.Body, 64<<10))
+        httpResp.Body.Close()
+
+        status.Err = nil
+        status.Method = method
+        status.StatusCode = httpResp.StatusCode
+        status.Status = httpResp.Status
+        status.FinalURL = httpResp.Request.URL.String()
+        if method == "HEAD" && (httpResp.StatusCode == http.StatusMethodNotAllowed || httpResp.StatusCode == http.StatusNotImplemented) {
+            continue
+        }
+        break
+    }
+    status.Latency = time.Since(start)
+    status.Redirected = status.FinalURL != "" && status.FinalURL != link.URL
+    status.OK = status.Err == nil && status.StatusCode >= 200 && status.StatusCode < 400
+    return status
+}
+
+// hostLimiter bounds the number of concurrent requests, overall and per host
+type hostLimiter struct {
+    global  chan struct{}
+    perHost int
+    hosts   map[string]chan struct{}
+    mu      sync.Mutex
+}
+
+func newHostLimiter(concurrency int, perHost int) *hostLimiter {
+    return &hostLimiter{
+        global:  make(chan struct{}, concurrency),
+        perHost: perHost,
+        hosts:   make(map[string]chan struct{}),
+    }
+}
+
+// acquire blocks until a request to the host is allowed and returns the function releasing it
+func (l *hostLimiter) acquire(host string) func() {
+    release, _ := l.acquireContext(context.Background(), host)
+    return release
+}
+
+// acquireContext is acquire, giving up with the context error once the context is cancelled
+func (l *hostLimiter) acquireContext(ctx context.Context, host string) (func(), error) {
+    l.mu.Lock()
+    hostSlots, found := l.hosts[host]
+    if !found {
+        hostSlots = make(chan struct{}, l.perHost)
+        l.hosts[host] = hostSlots
+    }
+    l.mu.Unlock()
+
+    // the host slot is acquired first, so requests waiting for a busy host do not hold global slots
+    select {
+    case hostSlots <- struct{}{}:
+    case <-ctx.Done():
+        return nil, ctx.Err()
+    }
+    select {
+    case l.global <- struct{}{}:
+    case <-ctx.Done():
+        <-hostSlots
+        return nil, ctx.Err()
+    }
+    return func() {
+        <-l.global
+        <-hostSlots
+    }, nil
+}
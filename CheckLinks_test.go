@@ -0,0 +1,142 @@
+package katsuragi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCheckLinks(t *testing.T) {
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        switch r.URL.Path {
+        case "/":
+            w.Header().Set("Content-Type", "text/html")
+            w.Write([]byte(`<html><body>
+                <a href="/ok">OK</a>
+                <a href="/missing">Missing</a>
+                <a href="/redirect">Redirect</a>
+                <a href="/nohead">No HEAD</a>
+                <a href="/broken">Broken GET</a>
+                <a href="/ok">OK again</a>
+                <a href="http://127.0.0.1:1/unreachable">Unreachable</a>
+                <a href="mailto:info@example.com">Mail</a>
+                </body></html>`))
+        case "/ok":
+            w.WriteHeader(http.StatusOK)
+        case "/redirect":
+            http.Redirect(w, r, "/ok", http.StatusMovedPermanently)
+        case "/nohead":
+            if r.Method == "HEAD" {
+                w.WriteHeader(http.StatusMethodNotAllowed)
+                return
+            }
+            w.Write([]byte("body"))
+        case "/broken":
+            if r.Method == "HEAD" {
+                w.WriteHeader(http.StatusMethodNotAllowed)
+                return
+            }
+            // the connection is closed without a response
+            conn, _, _ := w.(http.Hijacker).Hijack()
+            conn.Close()
+        default:
+            w.WriteHeader(http.StatusNotFound)
+        }
+    }))
+    defer server.Close()
+    f := NewFetcher(&FetcherProps{Timeout: 3000, CacheCap: 10})
+    defer f.ClearCache()
+
+    results, err := f.CheckLinks(server.URL, CheckLinksProps{})
+    if err != nil {
+        t.Fatalf("Expected no error, got: %v", err)
+    }
+
+    expected := []struct {
+        url         string
+        ok          bool
+        statusCode  int
+        method      string
+        finalURL    string
+        occurrences int
+    }{
+        {server.URL + "/ok", true, 200, "HEAD", server.URL + "/ok", 2},
+        {server.URL + "/missing", false, 404, "HEAD", server.URL + "/missing", 1},
+        {server.URL + "/redirect", true, 200, "HEAD", server.URL + "/ok", 1},
+        {server.URL + "/nohead", true, 200, "GET", server.URL + "/nohead", 1},
+        // the failed GET fallback does not report the HEAD response
+        {server.URL + "/broken", false, 0, "", "", 1},
+        {"http://127.0.0.1:1/unreachable", false, 0, "", "", 1},
+    }
+    if len(results) != len(expected) {
+        t.Fatalf("Expected %d results, got %d: %+v", len(expected), len(results), results)
+    }
+    for i, tt := range expected {
+        result := results[i]
+        if result.Link.URL != tt.url || result.OK != tt.ok || result.StatusCode != tt.statusCode || result.Method != tt.method || result.FinalURL != tt.finalURL || result.Link.Occurrences != tt.occurrences {
+            t.Errorf("Expected %+v, got %+v", tt, result)
+        }
+        if result.Redirected != (tt.url != tt.finalURL && tt.finalURL != "") {
+            t.Errorf("%s: unexpected Redirected %v", tt.url, result.Redirected)
+        }
+        if result.Latency <= 0 {
+            t.Errorf("%s: expected latency to be measured", tt.url)
+        }
+    }
+    if results[4].Err == nil || results[5].Err == nil {
+        t.Errorf("Expected errors for the broken and unreachable links")
+    }
+
+    // filters and errors
+    results, err = f.CheckLinks(server.URL, CheckLinksProps{Filters: []LinkFilter{func(link Link) bool { return strings.HasSuffix(link.URL, "/missing") }}})
+    if err != nil || len(results) != 1 {
+        t.Errorf("Expected 1 result, got %+v (%v)", results, err)
+    }
+    if _, err := f.CheckLinks(server.URL, CheckLinksProps{Category: "unknown"}); err == nil {
+        t.Errorf("Expected error for invalid category, got none")
+    }
+    empty := MockServer(t, `<html><body></body></html>`)
+    defer empty.Close()
+    if _, err := f.CheckLinks(empty.URL, CheckLinksProps{}); err == nil || err.Error() != "CheckLinks failed to find any links in HTML" {
+        t.Errorf("Expected error, got %v", err)
+    }
+}
+
+func TestCheckLinks_PerHostConcurrency(t *testing.T) {
+    var mu sync.Mutex
+    current, maxConcurrent := 0, 0
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path == "/" {
+            var page strings.Builder
+            page.WriteString("<html><body>")
+            for _, path := range []string{"a", "b", "c", "d", "e", "f"} {
+                page.WriteString(`<a href="/` + path + `">` + path + `</a>`)
+            }
+            page.WriteString("</body></html>")
+            w.Header().Set("Content-Type", "text/html")
+            w.Write([]byte(page.String()))
+            return
+        }
+        mu.Lock()
+        current++
+        maxConcurrent = max(maxConcurrent, current)
+        mu.Unlock()
+        time.Sleep(20 * time.Millisecond)
+        mu.Lock()
+        current--
+        mu.Unlock()
+    }))
+    defer server.Close()
+    f := NewFetcher(&FetcherProps{Timeout: 3000, CacheCap: 10})
+
+    results, err := f.CheckLinks(server.URL, CheckLinksProps{PerHostConcurrency: 2})
+    if err != nil || len(results) != 6 {
+        t.Fatalf("Expected 6 results, got %d (%v)", len(results), err)
+    }
+    if maxConcurrent > 2 {
+        t.Errorf("Expected at most 2 concurrent requests, got %d", maxConcurrent)
+    }
+}
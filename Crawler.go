@@ -0,0 +1,231 @@
+package katsuragi
+
+import (
+	"context"
+	"fmt"
+	Url "net/url"
+	"sync"
+)
+
+const (
+    defaultCrawlerMaxDepth           = 3
+    defaultCrawlerMaxPages           = 100
+    defaultCrawlerConcurrency        = 10
+    defaultCrawlerPerHostConcurrency = 2
+)
+
+// NewCrawler creates a Crawler fetching pages with the Fetcher (timeout, User-Agent and cache).
+// Unset props are replaced with their defaults, see CrawlerProps.
+func NewCrawler(f *Fetcher, props CrawlerProps) *Crawler {
+    maxDepth := defaultCrawlerMaxDepth
+    if props.MaxDepth != nil && *props.MaxDepth >= 0 {
+        maxDepth = *props.MaxDepth
+    }
+    if props.MaxPages <= 0 {
+        props.MaxPages = defaultCrawlerMaxPages
+    }
+    if props.Category == "" {
+        props.Category = LinkCategoryInternal
+    }
+    if props.Concurrency <= 0 {
+        props.Concurrency = defaultCrawlerConcurrency
+    }
+    if props.PerHostConcurrency <= 0 {
+        props.PerHostConcurrency = defaultCrawlerPerHostConcurrency
+    }
+    return &Crawler{
+        fetcher:  f,
+        props:    props,
+        maxDepth: maxDepth,
+        robots:   make(map[string]*robotsTxt),
+    }
+}
+
+// Crawl starts crawling from the seeds in the background and sends every fetched page on the returned channel,
+// which is closed once the crawl is over or the context is cancelled
+func (c *Crawler) Crawl(ctx context.Context, seeds ...string) (<-chan CrawlResult, error) {
+    if !validLinkCategories[c.props.Category] {
+        return nil, fmt.Errorf("Crawl failed to filter links. Invalid category: %v", c.props.Category)
+    }
+    results := make(chan CrawlResult)
+    go func() {
+        defer close(results)
+        c.Run(ctx, func(result CrawlResult) {
+            select {
+            case results <- result:
+            case <-ctx.Done():
+            }
+        }, seeds...)
+    }()
+    return results, nil
+}
+
+// crawlTarget is a URL waiting to be fetched
+type crawlTarget struct {
+    url      string
+    referrer string
+}
+
+// Run crawls breadth-first from the seeds and calls onPage for every fetched page, from a single goroutine.
+// Pages of the same depth are fetched concurrently, bounded by Concurrency and PerHostConcurrency.
+// Every URL is fetched once (see normalizeURL) and pages disallowed by robots.txt are skipped.
+// Run returns once MaxDepth or MaxPages is reached, no links are left or the context is cancelled.
+func (c *Crawler) Run(ctx context.Context, onPage func(CrawlResult), seeds ...string) error {
+    if !validLinkCategories[c.props.Category] {
+        return fmt.Errorf("Run failed to filter links. Invalid category: %v", c.props.Category)
+    }
+
+    visited := make(map[string]bool)
+    var level []crawlTarget
+    for _, seed := range seeds {
+        if key := normalizeURL(seed); !visited[key] {
+            visited[key] = true
+            level = append(level, crawlTarget{url: seed})
+        }
+    }
+
+    limiter := newHostLimiter(c.props.Concurrency, c.props.PerHostConcurrency)
+    pages := 0
+    for depth := 0; depth <= c.maxDepth && len(level) > 0; depth++ {
+        // keep the pages allowed by robots.txt, within the page budget
+        var batch []crawlTarget
+        for _, target := range level {
+            if pages >= c.props.MaxPages {
+                break
+            }
+            if !c.allowed(target.url) {
+                continue
+            }
+            batch = append(batch, target)
+            pages++
+        }
+
+        type pageResult struct {
+            index  int
+            result CrawlResult
+            follow []Link
+        }
+        pageResults := make(chan pageResult)
+        var wg sync.WaitGroup
+        for i, target := range batch {
+            wg.Add(1)
+            go func(i int, target crawlTarget) {
+                defer wg.Done()
+                host := ""
+                if parsedUrl, err := Url.Parse(target.url); err == nil {
+                    host = parsedUrl.Host
+                }
+                release, err := limiter.acquireContext(ctx, host)
+                // the context may be cancelled while the slot is acquired
+                if err == nil && ctx.Err() != nil {
+                    release()
+                    err = ctx.Err()
+                }
+                if err != nil {
+                    pageResults <- pageResult{index: i, result: CrawlResult{URL: target.url, Depth: depth, Referrer: target.referrer, Err: err}}
+                    return
+                }
+                result, follow := c.crawlPage(ctx, target, depth)
+                release()
+                pageResults <- pageResult{index: i, result: result, follow: follow}
+            }(i, target)
+        }
+        go func() {
+            wg.Wait()
+            close(pageResults)
+        }()
+
+        // results are reported as soon as they arrive, links are queued in document order
+        follows := make([][]Link, len(batch))
+        for pageResult := range pageResults {
+            if ctx.Err() == nil {
+                onPage(pageResult.result)
+            }
+            follows[pageResult.index] = pageResult.follow
+        }
+        if ctx.Err() != nil {
+            return ctx.Err()
+        }
+
+        level = nil
+        for i, links := range follows {
+            for _, link := range links {
+                if key := normalizeURL(link.URL); !visited[key] && c.matchPatterns(link.URL) {
+                    visited[key] = true
+                    level = append(level, crawlTarget{url: link.URL, referrer: batch[i].url})
+                }
+            }
+        }
+    }
+    return nil
+}
+
+// crawlPage fetches the page and returns its result along with the links to follow
+func (c *Crawler) crawlPage(ctx context.Context, target crawlTarget, depth int) (CrawlResult, []Link) {
+    result := CrawlResult{URL: target.url, Depth: depth, Referrer: target.referrer}
+    document, _, err := retrieveResponse(ctx, target.url, c.fetcher)
+    if err != nil {
+        result.Err = err
+        return result, nil
+    }
+    result.Title, _ = traverseAndExtractTitle(document.root)
+    result.Description, _ = traverseAndExtractDescription(document.root)
+    if language, found := extractLanguage(document.root, document.responseHeader()); found {
+        result.Language = language
+    }
+    result.Links = extractLinkDetails(document.root, GetLinksProps{
+        Url:      target.url,
+        Category: LinkCategoryAll,
+        Kinds:    []LinkKind{LinkKindWeb},
+        Dedupe:   LinkDedupeNormalized,
+    })
+    if depth >= c.maxDepth {
+        return result, nil
+    }
+    follow := extractLinkDetails(document.root, GetLinksProps{
+        Url:      target.url,
+        Category: c.props.Category,
+        Kinds:    []LinkKind{LinkKindWeb},
+        Filters:  c.props.Filters,
+        Dedupe:   LinkDedupeNormalized,
+    })
+    return result, follow
+}
+
+// matchPatterns checks if the URL matches one of the Include patterns (if any) and none of the Exclude patterns
+func (c *Crawler) matchPatterns(url string) bool {
+    for _, pattern := range c.props.Exclude {
+        if pattern.MatchString(url) {
+            return false
+        }
+    }
+    if len(c.props.Include) == 0 {
+        return true
+    }
+    for _, pattern := range c.props.Include {
+        if pattern.MatchString(url) {
+            return true
+        }
+    }
+    return false
+}
+
+// allowed checks the robots.txt of the URL's host, fetched once per host
+func (c *Crawler) allowed(url string) bool {
+    if c.props.IgnoreRobots {
+        return true
+    }
+    parsedUrl, err := Url.Parse(url)
+    if err != nil {
+        return false
+    }
+    key := parsedUrl.Scheme + "://" + parsedUrl.Host
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    robots, found := c.robots[key]
+    if !found {
+        robots = getRobotsTxt(url, c.fetcher)
+        c.robots[key] = robots
+    }
+    return robots.allowed(c.fetcher.props.UserAgent, url)
+}
@@ -0,0 +1,241 @@
+package katsuragi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newCrawlerTestServer serves a small site: / links to /a, /b, /private/secret, /skip and an external page,
+// /a links to /c, /c links to /d
+func newCrawlerTestServer(t *testing.T) (*httptest.Server, *int) {
+    t.Helper()
+    pages := map[string]string{
+        "/": `<html><head><title>Home</title><meta name="description" content="Home page"></head><body>
+            <a href="/a">A</a> <a href="/b">B</a> <a href="/a#top">A again</a>
+            <a href="/private/secret">Secret</a> <a href="/skip">Skip</a>
+            <a href="https://external.example.org/">External</a></body></html>`,
+        "/a":              `<html><head><title>A</title></head><body><a href="/c">C</a> <a href="/">Home</a></body></html>`,
+        "/b":              `<html><head><title>B</title></head><body><a href="/missing">Missing</a></body></html>`,
+        "/c":              `<html><head><title>C</title></head><body><a href="/d">D</a></body></html>`,
+        "/d":              `<html><head><title>D</title></head><body></body></html>`,
+        "/private/secret": `<html><head><title>Secret</title></head><body></body></html>`,
+        "/skip":           `<html><head><title>Skip</title></head><body></body></html>`,
+    }
+    var mu sync.Mutex
+    requests := 0
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path == "/robots.txt" {
+            w.Write([]byte("User-agent: *\nDisallow: /private/\n"))
+            return
+        }
+        page, found := pages[r.URL.Path]
+        if !found {
+            w.WriteHeader(http.StatusNotFound)
+            return
+        }
+        mu.Lock()
+        requests++
+        mu.Unlock()
+        w.Header().Set("Content-Type", "text/html")
+        w.Write([]byte(page))
+    }))
+    t.Cleanup(server.Close)
+    return server, &requests
+}
+
+func crawledPaths(server *httptest.Server, results []CrawlResult) []string {
+    var paths []string
+    for _, result := range results {
+        paths = append(paths, result.URL[len(server.URL):])
+    }
+    sort.Strings(paths)
+    return paths
+}
+
+func intPtr(value int) *int {
+    return &value
+}
+
+func TestCrawlerRun(t *testing.T) {
+    server, _ := newCrawlerTestServer(t)
+
+    tests := []struct {
+        name  string
+        props CrawlerProps
+        want  []string
+    }{
+        {
+            name:  "Default limits",
+            props: CrawlerProps{Category: LinkCategorySameHost},
+            want:  []string{"/", "/a", "/b", "/c", "/d", "/missing", "/skip"},
+        },
+        {
+            name:  "Max depth",
+            props: CrawlerProps{Category: LinkCategorySameHost, MaxDepth: intPtr(1)},
+            want:  []string{"/", "/a", "/b", "/skip"},
+        },
+        {
+            name:  "Seeds only",
+            props: CrawlerProps{Category: LinkCategorySameHost, MaxDepth: intPtr(0)},
+            want:  []string{"/"},
+        },
+        {
+            name:  "Max pages",
+            props: CrawlerProps{Category: LinkCategorySameHost, MaxPages: 2},
+            want:  []string{"/", "/a"},
+        },
+        {
+            name:  "Exclude pattern",
+            props: CrawlerProps{Category: LinkCategorySameHost, Exclude: []*regexp.Regexp{regexp.MustCompile(`/(skip|missing)$`)}},
+            want:  []string{"/", "/a", "/b", "/c", "/d"},
+        },
+        {
+            name:  "Include pattern",
+            props: CrawlerProps{Category: LinkCategorySameHost, Include: []*regexp.Regexp{regexp.MustCompile(`/[ac]$`)}},
+            want:  []string{"/", "/a", "/c"},
+        },
+        {
+            name:  "Ignore robots.txt",
+            props: CrawlerProps{Category: LinkCategorySameHost, MaxDepth: intPtr(1), IgnoreRobots: true},
+            want:  []string{"/", "/a", "/b", "/private/secret", "/skip"},
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            crawler := NewCrawler(NewFetcher(nil), tt.props)
+            var results []CrawlResult
+            err := crawler.Run(context.Background(), func(result CrawlResult) {
+                results = append(results, result)
+            }, server.URL+"/")
+            if err != nil {
+                t.Fatalf("Run() error = %v", err)
+            }
+            if got := crawledPaths(server, results); !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("crawled %v, want %v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestCrawlerResults(t *testing.T) {
+    server, requests := newCrawlerTestServer(t)
+
+    crawler := NewCrawler(NewFetcher(nil), CrawlerProps{Category: LinkCategorySameHost, MaxDepth: intPtr(1)})
+    results, err := crawler.Crawl(context.Background(), server.URL+"/", server.URL)
+    if err != nil {
+        t.Fatalf("Crawl() error = %v", err)
+    }
+
+    byPath := make(map[string]CrawlResult)
+    for result := range results {
+        byPath[result.URL[len(server.URL):]] = result
+    }
+
+    home, found := byPath["/"]
+    if !found {
+        t.Fatalf("seed not crawled: %v", byPath)
+    }
+    if _, found := byPath[""]; found {
+        t.Errorf("duplicated seed crawled twice")
+    }
+    if home.Title != "Home" || home.Description != "Home page" || home.Depth != 0 || home.Referrer != "" {
+        t.Errorf("unexpected seed result %+v", home)
+    }
+    // outgoing links include external links, deduplicated
+    if len(home.Links) != 5 {
+        t.Errorf("len(Links) = %d, want 5: %v", len(home.Links), home.Links)
+    }
+    if a := byPath["/a"]; a.Depth != 1 || a.Referrer != server.URL+"/" || a.Title != "A" {
+        t.Errorf("unexpected result %+v", a)
+    }
+    if _, found := byPath["/private/secret"]; found {
+        t.Errorf("page disallowed by robots.txt was crawled")
+    }
+    if *requests != 4 {
+        t.Errorf("requests = %d, want 4", *requests)
+    }
+}
+
+func TestCrawlerErrors(t *testing.T) {
+    server, _ := newCrawlerTestServer(t)
+
+    crawler := NewCrawler(NewFetcher(nil), CrawlerProps{Category: LinkCategorySameHost, MaxDepth: intPtr(2)})
+    var missing *CrawlResult
+    crawler.Run(context.Background(), func(result CrawlResult) {
+        if result.URL == server.URL+"/missing" {
+            missing = &result
+        }
+    }, server.URL+"/")
+    if missing == nil || missing.Err == nil || missing.Referrer != server.URL+"/b" {
+        t.Errorf("expected an error for the missing page, got %+v", missing)
+    }
+
+    invalid := NewCrawler(NewFetcher(nil), CrawlerProps{Category: "invalid"})
+    if _, err := invalid.Crawl(context.Background(), server.URL); err == nil {
+        t.Errorf("expected an error for an invalid category")
+    }
+
+    // a cancelled crawl stops without reporting pages
+    ctx, cancel := context.WithCancel(context.Background())
+    cancel()
+    results, _ := NewCrawler(NewFetcher(nil), CrawlerProps{}).Crawl(ctx, server.URL)
+    for result := range results {
+        t.Errorf("unexpected result after cancellation %+v", result)
+    }
+}
+
+func TestCrawlerCancel(t *testing.T) {
+    var mu sync.Mutex
+    fetches := 0
+    started := make(chan struct{}, 10)
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        mu.Lock()
+        fetches++
+        mu.Unlock()
+        started <- struct{}{}
+        // slow pages, answered once the request is aborted
+        select {
+        case <-r.Context().Done():
+        case <-time.After(5 * time.Second):
+        }
+        w.Header().Set("Content-Type", "text/html")
+        w.Write([]byte(`<html><head></head><body></body></html>`))
+    }))
+    defer server.Close()
+
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+    crawler := NewCrawler(NewFetcher(nil), CrawlerProps{Concurrency: 1, PerHostConcurrency: 1, IgnoreRobots: true})
+    results, err := crawler.Crawl(ctx, server.URL+"/1", server.URL+"/2", server.URL+"/3")
+    if err != nil {
+        t.Fatalf("Crawl() error = %v", err)
+    }
+    <-started
+    cancel()
+
+    done := make(chan struct{})
+    go func() {
+        for range results {
+        }
+        close(done)
+    }()
+    select {
+    case <-done:
+    case <-time.After(time.Second):
+        t.Fatalf("the results channel was not closed after cancellation")
+    }
+    time.Sleep(100 * time.Millisecond)
+    mu.Lock()
+    defer mu.Unlock()
+    if fetches != 1 {
+        t.Errorf("fetches = %d, want 1: no fetch should start after cancellation", fetches)
+    }
+}
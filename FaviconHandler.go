@@ -0,0 +1,202 @@
+package katsuragi
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"html"
+	"net/http"
+	Url "net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+    defaultFaviconHandlerSize     = 32
+    maxFaviconHandlerSize         = 1024
+    defaultFaviconHandlerCacheCap = 1000
+    // icons are kept for a day, letter avatars for an hour as the website may add an icon later
+    faviconMaxAge      = 24 * time.Hour
+    letterAvatarMaxAge = time.Hour
+)
+
+// faviconContentSecurityPolicy prevents the icons, which come from third-party websites, from running scripts
+// or loading anything when opened directly
+const faviconContentSecurityPolicy = "default-src 'none'; style-src 'unsafe-inline'; sandbox"
+
+// FaviconHandler returns an http.Handler which serves the best icon of a website, e.g. `/icon?url=https://example.com&size=64`.
+// The icon is resolved with GetBestIcon, downloaded and cached together with its ETag until its max-age expires.
+// When no icon can be found, a letter avatar (SVG) generated from the hostname is served instead.
+// Icons are served with a restrictive Content-Security-Policy and nosniff. SVG icons of websites may hold scripts,
+// so they are served as attachments unless FaviconHandlerProps.AllowSVG is set.
+// props may be nil, see FaviconHandlerProps for the defaults.
+func FaviconHandler(f *Fetcher, props *FaviconHandlerProps) http.Handler {
+    handler := &faviconHandler{
+        fetcher:  f,
+        cacheCap: defaultFaviconHandlerCacheCap,
+        cache:    make(map[string]*list.Element),
+        lruList:  list.New(),
+    }
+    if props != nil {
+        if props.CacheCap > 0 {
+            handler.cacheCap = props.CacheCap
+        }
+        handler.allowSVG = props.AllowSVG
+    }
+    return handler
+}
+
+type faviconHandler struct {
+    fetcher  *Fetcher
+    cacheCap int
+    allowSVG bool
+    cache    map[string]*list.Element
+    lruList  *list.List
+    mu       sync.Mutex
+}
+
+type faviconCacheEntry struct {
+    key          string
+    data         []byte
+    contentType  string
+    etag         string
+    cacheControl string
+    expires      time.Time
+    attachment   bool // third-party SVG served as a download, see FaviconHandlerProps.AllowSVG
+}
+
+func (h *faviconHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("X-Content-Type-Options", "nosniff")
+    w.Header().Set("Content-Security-Policy", faviconContentSecurityPolicy)
+    if r.Method != http.MethodGet && r.Method != http.MethodHead {
+        w.Header().Set("Allow", "GET, HEAD")
+        http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+        return
+    }
+
+    pageUrl := r.URL.Query().Get("url")
+    parsedUrl, err := Url.Parse(pageUrl)
+    if pageUrl == "" || err != nil || (parsedUrl.Scheme != "http" && parsedUrl.Scheme != "https") || parsedUrl.Host == "" {
+        http.Error(w, "missing or invalid url parameter", http.StatusBadRequest)
+        return
+    }
+    size := defaultFaviconHandlerSize
+    if sizeParam := r.URL.Query().Get("size"); sizeParam != "" {
+        size, err = strconv.Atoi(sizeParam)
+        if err != nil || size <= 0 || size > maxFaviconHandlerSize {
+            http.Error(w, fmt.Sprintf("size parameter must be between 1 and %d", maxFaviconHandlerSize), http.StatusBadRequest)
+            return
+        }
+    }
+
+    key := pageUrl + "|" + strconv.Itoa(size)
+    entry, found := h.getFromCache(key)
+    if !found {
+        entry = h.resolve(pageUrl, parsedUrl.Hostname(), size)
+        entry.key = key
+        h.addToCache(entry)
+    }
+
+    w.Header().Set("Content-Type", entry.contentType)
+    w.Header().Set("Cache-Control", entry.cacheControl)
+    w.Header().Set("ETag", entry.etag)
+    if entry.attachment {
+        w.Header().Set("Content-Disposition", "attachment")
+    }
+    // ServeContent handles HEAD and If-None-Match
+    http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(entry.data))
+}
+
+// resolve downloads the best icon of the page, falling back to a letter avatar
+func (h *faviconHandler) resolve(pageUrl string, hostname string, size int) *faviconCacheEntry {
+    entry := &faviconCacheEntry{}
+    if icon, iconImage, err := h.fetcher.bestIcon(pageUrl, size); err == nil {
+        // icons measured by bestIcon are already downloaded
+        if iconImage == nil {
+            iconImage, err = h.fetcher.GetIconImage(icon.URL)
+        }
+        if err == nil {
+            entry.data = iconImage.Data
+            entry.contentType = iconImage.MIMEType
+            entry.attachment = iconImage.Format == "svg" && !h.allowSVG
+        }
+    }
+    maxAge := faviconMaxAge
+    if entry.data == nil {
+        entry.data = letterAvatar(hostname, size)
+        entry.contentType = "image/svg+xml"
+        maxAge = letterAvatarMaxAge
+    }
+    entry.cacheControl = "public, max-age=" + strconv.Itoa(int(maxAge.Seconds()))
+    entry.expires = time.Now().Add(maxAge)
+    sum := sha1.Sum(entry.data)
+    entry.etag = `"` + hex.EncodeToString(sum[:]) + `"`
+    return entry
+}
+
+func (h *faviconHandler) getFromCache(key string) (*faviconCacheEntry, bool) {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+
+    if elem, ok := h.cache[key]; ok {
+        entry := elem.Value.(*faviconCacheEntry)
+        if time.Now().After(entry.expires) {
+            // resolved again by the caller
+            delete(h.cache, key)
+            h.lruList.Remove(elem)
+            return nil, false
+        }
+        h.lruList.MoveToFront(elem)
+        return entry, true
+    }
+    return nil, false
+}
+
+func (h *faviconHandler) addToCache(entry *faviconCacheEntry) {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+
+    if elem, ok := h.cache[entry.key]; ok {
+        h.lruList.MoveToFront(elem)
+        elem.Value = entry
+        return
+    }
+
+    // Evict the least recently used entry if the cache is full
+    if len(h.cache) >= h.cacheCap {
+        oldest := h.lruList.Back()
+        if oldest != nil {
+            delete(h.cache, oldest.Value.(*faviconCacheEntry).key)
+            h.lruList.Remove(oldest)
+        }
+    }
+
+    h.cache[entry.key] = h.lruList.PushFront(entry)
+}
+
+// letterAvatar generates a square SVG image with the first letter of the hostname.
+// The background color is derived from the hostname, so it's stable across requests.
+func letterAvatar(hostname string, size int) []byte {
+    name := strings.TrimPrefix(strings.ToLower(hostname), "www.")
+    letter := "?"
+    if r, _ := utf8.DecodeRuneInString(name); r != utf8.RuneError && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+        letter = string(unicode.ToUpper(r))
+    }
+
+    hash := fnv.New32a()
+    hash.Write([]byte(name))
+    hue := hash.Sum32() % 360
+
+    svg := fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 100 100">`+
+        `<rect width="100" height="100" rx="12" fill="hsl(%d, 55%%, 45%%)"/>`+
+        `<text x="50" y="50" dy="0.35em" text-anchor="middle" font-family="sans-serif" font-size="60" fill="#ffffff">%s</text>`+
+        `</svg>`, size, size, hue, html.EscapeString(letter))
+    return []byte(svg)
+}
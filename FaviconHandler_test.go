@@ -0,0 +1,227 @@
+package katsuragi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFaviconHandler(t *testing.T) {
+    icon := testPng(64, 64)
+    requests := 0
+    site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        switch r.URL.Path {
+        case "/":
+            requests++
+            w.Header().Set("Content-Type", "text/html")
+            w.Write([]byte(`<html><head><link rel="icon" type="image/png" href="/favicon.png" sizes="64x64"></head><body></body></html>`))
+        case "/favicon.png":
+            w.Write(icon)
+        default:
+            w.WriteHeader(http.StatusNotFound)
+        }
+    }))
+    defer site.Close()
+    // pages without any icon
+    emptySite := MockServer(t, `<html><head></head><body></body></html>`)
+    defer emptySite.Close()
+
+    // the Fetcher cache is cleared between requests, so the handler cache is tested
+    f := NewFetcher(&FetcherProps{Timeout: 3000, CacheCap: 10})
+    handler := FaviconHandler(f, nil)
+
+    serve := func(method string, target string, header http.Header) *httptest.ResponseRecorder {
+        req := httptest.NewRequest(method, target, nil)
+        for key, values := range header {
+            req.Header[key] = values
+        }
+        rec := httptest.NewRecorder()
+        handler.ServeHTTP(rec, req)
+        f.ClearCache()
+        return rec
+    }
+
+    iconTarget := "/icon?size=64&url=" + url.QueryEscape(site.URL)
+
+    // Icon
+    rec := serve("GET", iconTarget, nil)
+    if rec.Code != http.StatusOK {
+        t.Fatalf("Expected status 200, got %d", rec.Code)
+    }
+    if rec.Header().Get("Content-Type") != "image/png" || rec.Body.String() != string(icon) {
+        t.Errorf("Expected PNG icon, got %s (%d bytes)", rec.Header().Get("Content-Type"), rec.Body.Len())
+    }
+    etag := rec.Header().Get("ETag")
+    if etag == "" || !strings.Contains(rec.Header().Get("Cache-Control"), "max-age=86400") {
+        t.Errorf("Expected ETag and Cache-Control, got %q, %q", etag, rec.Header().Get("Cache-Control"))
+    }
+
+    // Cached icon with ETag
+    rec = serve("GET", iconTarget, http.Header{"If-None-Match": {etag}})
+    if rec.Code != http.StatusNotModified {
+        t.Errorf("Expected status 304, got %d", rec.Code)
+    }
+    if requests != 1 {
+        t.Errorf("Expected the icon to be served from cache, website was requested %d times", requests)
+    }
+
+    // Letter avatar fallback
+    rec = serve("GET", "/icon?url="+url.QueryEscape(emptySite.URL), nil)
+    if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "image/svg+xml" {
+        t.Fatalf("Expected SVG letter avatar, got %d %s", rec.Code, rec.Header().Get("Content-Type"))
+    }
+    if !strings.Contains(rec.Body.String(), `width="32"`) || !strings.Contains(rec.Body.String(), ">1</text>") {
+        t.Errorf("Unexpected letter avatar: %s", rec.Body.String())
+    }
+
+    // Bad requests
+    badRequests := []struct {
+        method       string
+        target       string
+        expectedCode int
+    }{
+        {"GET", "/icon", http.StatusBadRequest},
+        {"GET", "/icon?url=ftp://example.com", http.StatusBadRequest},
+        {"GET", "/icon?url=" + url.QueryEscape(site.URL) + "&size=abc", http.StatusBadRequest},
+        {"GET", "/icon?url=" + url.QueryEscape(site.URL) + "&size=4096", http.StatusBadRequest},
+        {"POST", iconTarget, http.StatusMethodNotAllowed},
+    }
+    for _, tt := range badRequests {
+        if rec := serve(tt.method, tt.target, nil); rec.Code != tt.expectedCode {
+            t.Errorf("%s %s: expected status %d, got %d", tt.method, tt.target, tt.expectedCode, rec.Code)
+        }
+    }
+}
+
+func TestFaviconHandler_Headers(t *testing.T) {
+    svg := `<svg xmlns="http://www.w3.org/2000/svg" width="64" height="64"><script>alert(1)</script></svg>`
+    icon := testPng(64, 64)
+    iconRequests := 0
+    site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        switch r.URL.Path {
+        case "/svg":
+            w.Header().Set("Content-Type", "text/html")
+            w.Write([]byte(`<html><head><link rel="icon" type="image/svg+xml" href="/favicon.svg"></head><body></body></html>`))
+        case "/png":
+            // no sizes, so the icon is measured by GetBestIcon
+            w.Header().Set("Content-Type", "text/html")
+            w.Write([]byte(`<html><head><link rel="icon" type="image/png" href="/favicon.png"></head><body></body></html>`))
+        case "/favicon.svg":
+            w.Header().Set("Content-Type", "image/svg+xml")
+            w.Write([]byte(svg))
+        case "/favicon.png":
+            iconRequests++
+            w.Write(icon)
+        default:
+            w.WriteHeader(http.StatusNotFound)
+        }
+    }))
+    defer site.Close()
+
+    serve := func(handler http.Handler, page string) *httptest.ResponseRecorder {
+        rec := httptest.NewRecorder()
+        handler.ServeHTTP(rec, httptest.NewRequest("GET", "/icon?url="+url.QueryEscape(site.URL+page), nil))
+        return rec
+    }
+
+    // SVG icons are served as attachments by default
+    rec := serve(FaviconHandler(NewFetcher(nil), nil), "/svg")
+    if rec.Code != http.StatusOK || rec.Body.String() != svg {
+        t.Fatalf("Expected SVG icon, got %d %s", rec.Code, rec.Body.String())
+    }
+    if rec.Header().Get("X-Content-Type-Options") != "nosniff" {
+        t.Errorf("Expected X-Content-Type-Options nosniff, got %q", rec.Header().Get("X-Content-Type-Options"))
+    }
+    if rec.Header().Get("Content-Security-Policy") != "default-src 'none'; style-src 'unsafe-inline'; sandbox" {
+        t.Errorf("Unexpected Content-Security-Policy %q", rec.Header().Get("Content-Security-Policy"))
+    }
+    if rec.Header().Get("Content-Disposition") != "attachment" {
+        t.Errorf("Expected SVG icon as attachment, got Content-Disposition %q", rec.Header().Get("Content-Disposition"))
+    }
+
+    // AllowSVG serves them inline
+    rec = serve(FaviconHandler(NewFetcher(nil), &FaviconHandlerProps{AllowSVG: true}), "/svg")
+    if rec.Header().Get("Content-Disposition") != "" || rec.Header().Get("Content-Security-Policy") == "" {
+        t.Errorf("Expected inline SVG icon with Content-Security-Policy, got Content-Disposition %q", rec.Header().Get("Content-Disposition"))
+    }
+
+    // measured icons are downloaded once, other formats are inline
+    rec = serve(FaviconHandler(NewFetcher(nil), nil), "/png")
+    if rec.Body.String() != string(icon) || rec.Header().Get("Content-Disposition") != "" {
+        t.Errorf("Expected inline PNG icon, got %s (%d bytes)", rec.Header().Get("Content-Type"), rec.Body.Len())
+    }
+    if iconRequests != 1 {
+        t.Errorf("Expected the icon to be downloaded once, got %d downloads", iconRequests)
+    }
+}
+
+func TestFaviconHandler_Cache(t *testing.T) {
+    requests := 0
+    site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path != "/" {
+            w.WriteHeader(http.StatusNotFound)
+            return
+        }
+        requests++
+        w.Header().Set("Content-Type", "text/html")
+        w.Write([]byte(`<html><head></head><body></body></html>`))
+    }))
+    defer site.Close()
+
+    f := NewFetcher(nil)
+    handler := FaviconHandler(f, &FaviconHandlerProps{CacheCap: 1}).(*faviconHandler)
+    serve := func(size string) {
+        rec := httptest.NewRecorder()
+        handler.ServeHTTP(rec, httptest.NewRequest("GET", "/icon?size="+size+"&url="+url.QueryEscape(site.URL), nil))
+        f.ClearCache()
+        if rec.Code != http.StatusOK || !strings.Contains(rec.Header().Get("Cache-Control"), "max-age=3600") {
+            t.Fatalf("Expected letter avatar, got %d %q", rec.Code, rec.Header().Get("Cache-Control"))
+        }
+    }
+
+    serve("32")
+    serve("32")
+    if requests != 1 {
+        t.Errorf("Expected the avatar to be served from cache, website was requested %d times", requests)
+    }
+
+    // expired entries are resolved again
+    for _, elem := range handler.cache {
+        elem.Value.(*faviconCacheEntry).expires = time.Now().Add(-time.Second)
+    }
+    serve("32")
+    if requests != 2 {
+        t.Errorf("Expected the expired avatar to be resolved again, website was requested %d times", requests)
+    }
+
+    // the handler has its own capacity
+    serve("64")
+    if len(handler.cache) != 1 || handler.lruList.Len() != 1 {
+        t.Errorf("Expected 1 cached icon, got %d", len(handler.cache))
+    }
+}
+
+func TestLetterAvatar(t *testing.T) {
+    tests := []struct {
+        hostname       string
+        expectedLetter string
+    }{
+        {"www.example.com", "E"},
+        {"github.com", "G"},
+        {"über.de", "Ü"},
+        {"", "?"},
+        {"-dash.com", "?"},
+    }
+    for _, tt := range tests {
+        svg := string(letterAvatar(tt.hostname, 16))
+        if !strings.Contains(svg, ">"+tt.expectedLetter+"</text>") {
+            t.Errorf("letterAvatar(%q): expected letter %s, got %s", tt.hostname, tt.expectedLetter, svg)
+        }
+    }
+    if string(letterAvatar("example.com", 16)) != string(letterAvatar("www.example.com", 16)) {
+        t.Errorf("Expected the same avatar with and without www.")
+    }
+}
@@ -0,0 +1,434 @@
+package katsuragi
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+const (
+    // minArticleTextLength is the minimum length of the text of an article
+    minArticleTextLength = 140
+    // minParagraphLength is the minimum length of a paragraph to be scored
+    minParagraphLength = 25
+    // wordsPerMinute is the reading speed used to estimate the reading time
+    wordsPerMinute = 200
+)
+
+var (
+    // unlikelyCandidatePattern matches the class and id of boilerplate blocks
+    unlikelyCandidatePattern = regexp.MustCompile(`(?i)-ad-|^ad-|\bads?\b|advert|agegate|banner|breadcrumb|combx|comment|community|cookie|cover-wrap|disqus|extra|footer|gdpr|header|legends|menu|modal|newsletter|pager|pagination|popup|promo|related|remark|replies|rss|share|shoutbox|sidebar|skyscraper|social|sponsor|subscribe|tweet|twitter|widget`)
+    // maybeCandidatePattern rescues blocks matching unlikelyCandidatePattern which may hold the content
+    maybeCandidatePattern = regexp.MustCompile(`(?i)and|article|body|column|content|main|shadow`)
+    // positiveClassPattern and negativeClassPattern weight the class and id of candidates
+    positiveClassPattern = regexp.MustCompile(`(?i)article|body|content|entry|hentry|h-entry|main|page|pagination|post|text|blog|story`)
+    negativeClassPattern = regexp.MustCompile(`(?i)-ad-|hidden|^hid$| hid$| hid |^hid |banner|combx|comment|com-|contact|foot|footer|footnote|gdpr|masthead|media|meta|outbrain|promo|related|scroll|share|shoutbox|sidebar|skyscraper|sponsor|shopping|tags|tool|widget`)
+)
+
+// articleBoilerplateTags never hold the content of an article
+var articleBoilerplateTags = map[string]bool{
+    "nav": true, "aside": true, "footer": true, "header": true, "form": true, "button": true, "input": true,
+    "select": true, "textarea": true, "iframe": true, "object": true, "embed": true, "dialog": true, "menu": true,
+}
+
+// articleKeptAttributes are the attributes kept in the cleaned HTML of an article
+var articleKeptAttributes = map[string]bool{
+    "href": true, "src": true, "srcset": true, "alt": true, "title": true, "datetime": true, "colspan": true, "rowspan": true,
+}
+
+// GetArticle extracts the main content of the given URL with readability-style scoring: paragraphs are scored
+// by their length and commas, their ancestors accumulate the scores weighted by their tag, class and link density,
+// and the best ancestor is kept along with its related siblings. Boilerplate (navigation, ads, comments, footers...)
+// is stripped from the content, which is returned as cleaned HTML and plain text along with the byline,
+// publish date, lead image, word count and reading time.
+func (f *Fetcher) GetArticle(url string) (*Article, error) {
+    document, err := retrieveDocument(url, f)
+    if err != nil {
+        return nil, err
+    }
+    article, found := extractArticle(document.root, url)
+    if !found {
+        return nil, fmt.Errorf("GetArticle failed to find article in HTML")
+    }
+    return article, nil
+}
+
+// Article extracts the main content of the document, see GetArticle
+func (d *Document) Article() (*Article, error) {
+    article, found := extractArticle(d.root, d.url)
+    if !found {
+        return nil, fmt.Errorf("Article failed to find article in HTML")
+    }
+    return article, nil
+}
+
+// extractArticle finds the content of the document and extracts its metadata.
+// The document is not modified, the content is cleaned on a copy.
+func extractArticle(doc *html.Node, url string) (*Article, bool) {
+    content := findArticleContent(doc)
+    if content == nil {
+        return nil, false
+    }
+    cleanArticleNode(content, url)
+
+    text := extractVisibleText(content)
+    if len(text) < minArticleTextLength {
+        return nil, false
+    }
+    var rendered bytes.Buffer
+    if err := html.Render(&rendered, content); err != nil {
+        return nil, false
+    }
+
+    article := &Article{
+        URL:       url,
+        HTML:      rendered.String(),
+        Text:      text,
+        WordCount: len(strings.Fields(text)),
+    }
+    article.ReadingTime = time.Duration(math.Ceil(float64(article.WordCount)/wordsPerMinute)) * time.Minute
+    article.Title, _ = traverseAndExtractTitle(doc)
+    article.Title = strings.TrimSpace(article.Title)
+    if description, found := traverseAndExtractDescription(doc); found {
+        article.Excerpt = strings.TrimSpace(description)
+    } else if firstParagraph, _, _ := strings.Cut(text, "\n"); firstParagraph != "" {
+        article.Excerpt = firstParagraph
+    }
+    article.Byline, article.Published, article.LeadImage = extractArticleMetadata(doc, url)
+    if article.LeadImage == "" {
+        if img := findFirstElement(content, "img"); img != nil {
+            article.LeadImage = extractAttributes(img.Attr)["src"]
+        }
+    }
+    return article, true
+}
+
+// findArticleContent scores the candidates of the document and returns a copy of the best one
+// wrapped in a <div> along with its related siblings, nil if no paragraph was found
+func findArticleContent(doc *html.Node) *html.Node {
+    scores := make(map[*html.Node]float64)
+    var candidates []*html.Node
+
+    // addScore initializes the score of a candidate with the weight of its tag and class
+    addScore := func(n *html.Node, score float64) {
+        if _, found := scores[n]; !found {
+            scores[n] = initialArticleScore(n)
+            candidates = append(candidates, n)
+        }
+        scores[n] += score
+    }
+
+    var walk func(*html.Node)
+    walk = func(n *html.Node) {
+        if n.Type == html.ElementNode {
+            if isHiddenElement(n) || articleBoilerplateTags[n.Data] || isUnlikelyCandidate(n) {
+                return
+            }
+            if isArticleParagraph(n) {
+                text := textContent(n)
+                if len(text) >= minParagraphLength {
+                    score := 1 + float64(strings.Count(text, ",")+strings.Count(text, "，")) + math.Min(float64(len(text))/100, 3)
+                    // the parent gets the full score, the grandparent half and the next ancestor a sixth
+                    level := 0
+                    for ancestor := n.Parent; ancestor != nil && ancestor.Type == html.ElementNode && level < 3; ancestor = ancestor.Parent {
+                        divider := 1.0
+                        if level == 1 {
+                            divider = 2
+                        } else if level > 1 {
+                            divider = float64(level * 3)
+                        }
+                        addScore(ancestor, score/divider)
+                        level++
+                    }
+                }
+                return
+            }
+        }
+        for c := n.FirstChild; c != nil; c = c.NextSibling {
+            walk(c)
+        }
+    }
+    walk(doc)
+
+    var top *html.Node
+    topScore := 0.0
+    for _, candidate := range candidates {
+        // links are navigation rather than content
+        scores[candidate] *= 1 - linkDensity(candidate)
+        if top == nil || scores[candidate] > topScore {
+            top, topScore = candidate, scores[candidate]
+        }
+    }
+    if top == nil {
+        return nil
+    }
+
+    // related siblings (e.g. paragraphs split by an image) are part of the content
+    container := &html.Node{Type: html.ElementNode, Data: "div"}
+    threshold := math.Max(10, topScore*0.2)
+    for sibling := top; sibling != nil; sibling = sibling.NextSibling {
+        if sibling == top || keepArticleSibling(sibling, scores, threshold) {
+            container.AppendChild(cloneNode(sibling))
+        }
+    }
+    for sibling := top.PrevSibling; sibling != nil; sibling = sibling.PrevSibling {
+        if keepArticleSibling(sibling, scores, threshold) {
+            container.InsertBefore(cloneNode(sibling), container.FirstChild)
+        }
+    }
+    return container
+}
+
+// keepArticleSibling checks if a sibling of the top candidate belongs to the content
+func keepArticleSibling(n *html.Node, scores map[*html.Node]float64, threshold float64) bool {
+    if n.Type != html.ElementNode || isHiddenElement(n) || articleBoilerplateTags[n.Data] {
+        return false
+    }
+    if score, found := scores[n]; found && score >= threshold {
+        return true
+    }
+    if n.Data == "p" {
+        text := textContent(n)
+        density := linkDensity(n)
+        return (len(text) > 80 && density < 0.25) || (len(text) > 0 && density == 0 && strings.Contains(text, ". "))
+    }
+    return false
+}
+
+// isArticleParagraph checks if the element is a block of text: a paragraph, or a <div> without block children
+func isArticleParagraph(n *html.Node) bool {
+    switch n.Data {
+    case "p", "pre", "td", "blockquote":
+        return true
+    case "div":
+        for c := n.FirstChild; c != nil; c = c.NextSibling {
+            if c.Type == html.ElementNode && (textBlockElements[c.Data] || c.Data == "img" || c.Data == "table") {
+                return false
+            }
+        }
+        return true
+    }
+    return false
+}
+
+// isUnlikelyCandidate checks if the class or id of the element matches boilerplate
+func isUnlikelyCandidate(n *html.Node) bool {
+    if n.Data == "body" || n.Data == "article" || n.Data == "main" || n.Data == "a" {
+        return false
+    }
+    attrMap := extractAttributes(n.Attr)
+    match := attrMap["class"] + " " + attrMap["id"]
+    if strings.TrimSpace(match) == "" {
+        return false
+    }
+    return unlikelyCandidatePattern.MatchString(match) && !maybeCandidatePattern.MatchString(match)
+}
+
+// initialArticleScore weights a candidate by its tag, class and id
+func initialArticleScore(n *html.Node) float64 {
+    score := 0.0
+    switch n.Data {
+    case "article", "main":
+        score += 10
+    case "div":
+        score += 5
+    case "pre", "td", "blockquote":
+        score += 3
+    case "address", "ol", "ul", "dl", "dd", "dt", "li":
+        score -= 3
+    case "h1", "h2", "h3", "h4", "h5", "h6", "th":
+        score -= 5
+    }
+    attrMap := extractAttributes(n.Attr)
+    for _, value := range []string{attrMap["class"], attrMap["id"]} {
+        if value == "" {
+            continue
+        }
+        if negativeClassPattern.MatchString(value) {
+            score -= 25
+        }
+        if positiveClassPattern.MatchString(value) {
+            score += 25
+        }
+    }
+    return score
+}
+
+// cleanArticleNode removes the boilerplate of the content, the attributes other than articleKeptAttributes
+// and the empty blocks, and resolves the URLs of links and images
+func cleanArticleNode(n *html.Node, url string) {
+    var next *html.Node
+    for c := n.FirstChild; c != nil; c = next {
+        next = c.NextSibling
+        switch c.Type {
+        case html.CommentNode:
+            n.RemoveChild(c)
+            continue
+        case html.ElementNode:
+        default:
+            continue
+        }
+        if isHiddenElement(c) || articleBoilerplateTags[c.Data] || isUnlikelyCandidate(c) || isLinkList(c) {
+            n.RemoveChild(c)
+            continue
+        }
+        cleanArticleNode(c, url)
+
+        var attrs []html.Attribute
+        for _, attr := range c.Attr {
+            if !articleKeptAttributes[attr.Key] {
+                continue
+            }
+            switch attr.Key {
+            case "href", "src":
+                attr.Val = ensureAbsoluteURL(strings.TrimSpace(attr.Val), url)
+            case "srcset":
+                var candidates []string
+                for _, candidate := range parseSrcset(attr.Val) {
+                    candidates = append(candidates, strings.TrimSpace(ensureAbsoluteURL(candidate.URL, url)+" "+candidate.Descriptor))
+                }
+                attr.Val = strings.Join(candidates, ", ")
+            }
+            attrs = append(attrs, attr)
+        }
+        c.Attr = attrs
+
+        if (c.Data == "p" || c.Data == "div" || c.Data == "section" || c.Data == "span") && strings.TrimSpace(textContent(c)) == "" && !hasMedia(c) {
+            n.RemoveChild(c)
+        }
+    }
+}
+
+// isLinkList checks if a list or block is mostly made of links, like related articles or share buttons
+func isLinkList(n *html.Node) bool {
+    switch n.Data {
+    case "ul", "ol", "div", "section", "table":
+    default:
+        return false
+    }
+    text := textContent(n)
+    return len(text) < 200 && linkDensity(n) > 0.5
+}
+
+// hasMedia checks if the element contains an image, a picture, a video or an audio element
+func hasMedia(n *html.Node) bool {
+    for _, tag := range []string{"img", "picture", "video", "audio", "figure"} {
+        if findFirstElement(n, tag) != nil {
+            return true
+        }
+    }
+    return false
+}
+
+// extractArticleMetadata returns the byline, the publish date and the lead image declared in the document
+func extractArticleMetadata(doc *html.Node, url string) (string, time.Time, string) {
+    var byline, leadImage string
+    var published time.Time
+    var traverse func(*html.Node)
+    traverse = func(n *html.Node) {
+        if n.Type == html.ElementNode {
+            attrMap := extractAttributes(n.Attr)
+            key := strings.ToLower(attrMap["name"] + attrMap["property"])
+            content := strings.TrimSpace(attrMap["content"])
+            switch {
+            case n.Data == "meta" && byline == "" && (key == "author" || key == "article:author") && !strings.HasPrefix(content, "http"):
+                byline = content
+            case n.Data == "meta" && published.IsZero() && (key == "article:published_time" || key == "date" || key == "pubdate" || key == "dc.date"):
+                published = parseFeedDate(content)
+            case n.Data == "meta" && leadImage == "" && (key == "og:image" || key == "twitter:image") && content != "":
+                leadImage = ensureAbsoluteURL(content, url)
+            case byline == "" && (strings.EqualFold(attrMap["rel"], "author") || strings.EqualFold(attrMap["itemprop"], "author") || hasClass(n, "byline") || hasClass(n, "author")):
+                byline = strings.Join(strings.Fields(textContent(n)), " ")
+            case published.IsZero() && strings.EqualFold(attrMap["itemprop"], "datePublished"):
+                value := attrMap["content"]
+                if value == "" {
+                    value = attrMap["datetime"]
+                }
+                published = parseFeedDate(value)
+            case published.IsZero() && n.Data == "time" && attrMap["datetime"] != "":
+                published = parseFeedDate(attrMap["datetime"])
+            }
+        }
+        for c := n.FirstChild; c != nil; c = c.NextSibling {
+            traverse(c)
+        }
+    }
+    traverse(doc)
+    byline = strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(byline, "By "), "by "))
+    return byline, published, leadImage
+}
+
+// hasClass checks if the class attribute of the element contains the class
+func hasClass(n *html.Node, class string) bool {
+    return contains(strings.Fields(strings.ToLower(extractAttributes(n.Attr)["class"])), class)
+}
+
+// textContent returns all the text of the node with collapsed whitespace
+func textContent(n *html.Node) string {
+    var text []string
+    var collect func(*html.Node)
+    collect = func(n *html.Node) {
+        if n.Type == html.TextNode {
+            text = append(text, n.Data)
+        }
+        for c := n.FirstChild; c != nil; c = c.NextSibling {
+            collect(c)
+        }
+    }
+    collect(n)
+    return strings.Join(strings.Fields(strings.Join(text, " ")), " ")
+}
+
+// linkDensity returns the share of the text of the node which is the text of links
+func linkDensity(n *html.Node) float64 {
+    textLength := len(textContent(n))
+    if textLength == 0 {
+        return 0
+    }
+    linkLength := 0
+    var collect func(*html.Node)
+    collect = func(n *html.Node) {
+        if n.Type == html.ElementNode && n.Data == "a" {
+            linkLength += len(textContent(n))
+            return
+        }
+        for c := n.FirstChild; c != nil; c = c.NextSibling {
+            collect(c)
+        }
+    }
+    collect(n)
+    return float64(linkLength) / float64(textLength)
+}
+
+// findFirstElement returns the first element with the tag in the node tree, nil if none
+func findFirstElement(n *html.Node, tag string) *html.Node {
+    if n.Type == html.ElementNode && n.Data == tag {
+        return n
+    }
+    for c := n.FirstChild; c != nil; c = c.NextSibling {
+        if found := findFirstElement(c, tag); found != nil {
+            return found
+        }
+    }
+    return nil
+}
+
+// cloneNode returns a deep copy of the node, detached from its parent and siblings
+func cloneNode(n *html.Node) *html.Node {
+    clone := &html.Node{
+        Type:      n.Type,
+        DataAtom:  n.DataAtom,
+        Data:      n.Data,
+        Namespace: n.Namespace,
+        Attr:      append([]html.Attribute(nil), n.Attr...),
+    }
+    for c := n.FirstChild; c != nil; c = c.NextSibling {
+        clone.AppendChild(cloneNode(c))
+    }
+    return clone
+}
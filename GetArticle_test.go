@@ -0,0 +1,99 @@
+package katsuragi
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const testArticlePage = `<html><head>
+    <title>How to grow tomatoes</title>
+    <meta name="description" content="A guide to growing tomatoes at home.">
+    <meta property="og:image" content="/images/tomatoes.jpg">
+    <meta property="article:published_time" content="2024-05-01T08:00:00Z">
+    </head><body>
+    <header><nav><a href="/">Home</a> <a href="/garden">Garden</a> <a href="/kitchen">Kitchen</a></nav></header>
+    <div class="sidebar"><p>Subscribe to our newsletter to get more tips, tricks and offers every week, straight to your inbox.</p></div>
+    <main>
+        <article class="post">
+            <h1>How to grow tomatoes</h1>
+            <p class="byline">By Jane Gardener</p>
+            <p>Tomatoes are one of the most rewarding plants to grow at home, and with a little care, patience and sunlight, anyone can harvest them.</p>
+            <p>Start with healthy seedlings, plant them in rich soil, and make sure they get at least six hours of direct sunlight every day.</p>
+            <figure><img src="/images/seedlings.jpg" alt="Seedlings" class="wide" style="width: 100%"></figure>
+            <p>Water them deeply, but not too often, and support the stems with stakes or cages as the plants grow taller and heavier.</p>
+            <p>Read the <a href="/guides/watering" class="inline" onclick="track()">watering guide</a> for more details, tips and common mistakes to avoid.</p>
+            <ul class="share"><li><a href="/share/twitter">Twitter</a></li><li><a href="/share/facebook">Facebook</a></li></ul>
+            <div class="comments"><p>Great article, thanks a lot, I will definitely try this in my garden this summer!</p></div>
+            <p></p>
+        </article>
+    </main>
+    <footer><p>Copyright 2024, Example Gardening. All rights reserved, no part of this website may be reproduced.</p></footer>
+    </body></html>`
+
+func TestDocumentArticle(t *testing.T) {
+    doc, _ := ParseString(testArticlePage, "https://www.example.com/tomatoes")
+    article, err := doc.Article()
+    if err != nil {
+        t.Fatalf("Article() error = %v", err)
+    }
+
+    if article.Title != "How to grow tomatoes" || article.Excerpt != "A guide to growing tomatoes at home." || article.Byline != "Jane Gardener" {
+        t.Errorf("unexpected metadata %+v", article)
+    }
+    if !article.Published.Equal(time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)) || article.LeadImage != "https://www.example.com/images/tomatoes.jpg" {
+        t.Errorf("unexpected published date or lead image %v %v", article.Published, article.LeadImage)
+    }
+
+    for _, want := range []string{"Tomatoes are one of the most rewarding", "six hours of direct sunlight", "support the stems", "watering guide"} {
+        if !strings.Contains(article.Text, want) || !strings.Contains(article.HTML, want) {
+            t.Errorf("content does not contain %q", want)
+        }
+    }
+    for _, boilerplate := range []string{"Kitchen", "newsletter", "Facebook", "Great article", "Copyright"} {
+        if strings.Contains(article.Text, boilerplate) || strings.Contains(article.HTML, boilerplate) {
+            t.Errorf("content contains boilerplate %q:\n%s", boilerplate, article.HTML)
+        }
+    }
+
+    // attributes are cleaned and URLs resolved
+    for _, want := range []string{`<a href="https://www.example.com/guides/watering">`, `<img src="https://www.example.com/images/seedlings.jpg" alt="Seedlings"/>`} {
+        if !strings.Contains(article.HTML, want) {
+            t.Errorf("HTML does not contain %q:\n%s", want, article.HTML)
+        }
+    }
+    if strings.Contains(article.HTML, "class=") || strings.Contains(article.HTML, "onclick") || strings.Contains(article.HTML, "<p></p>") {
+        t.Errorf("HTML not cleaned:\n%s", article.HTML)
+    }
+
+    if article.WordCount != len(strings.Fields(article.Text)) || article.WordCount < 50 || article.ReadingTime != time.Minute {
+        t.Errorf("WordCount = %d, ReadingTime = %v", article.WordCount, article.ReadingTime)
+    }
+
+    // the document is not modified
+    if title, _ := doc.Title(); title != "How to grow tomatoes" {
+        t.Errorf("document modified")
+    }
+    if again, _ := doc.Article(); again == nil || again.HTML != article.HTML {
+        t.Errorf("second extraction differs")
+    }
+}
+
+func TestGetArticle(t *testing.T) {
+    server := MockServer(t, testArticlePage)
+    defer server.Close()
+
+    article, err := NewFetcher(nil).GetArticle(server.URL)
+    if err != nil {
+        t.Fatalf("GetArticle() error = %v", err)
+    }
+    if article.URL != server.URL || article.LeadImage != server.URL+"/images/tomatoes.jpg" {
+        t.Errorf("unexpected article %+v", article)
+    }
+
+    empty := MockServer(t, `<html><body><nav><a href="/">Home</a></nav><p>Short text.</p></body></html>`)
+    defer empty.Close()
+    if _, err := NewFetcher(nil).GetArticle(empty.URL); err == nil || err.Error() != "GetArticle failed to find article in HTML" {
+        t.Errorf("expected an error without article, got %v", err)
+    }
+}
@@ -0,0 +1,180 @@
+package katsuragi
+
+import (
+	"fmt"
+	"math"
+	Url "net/url"
+	"path"
+	"sort"
+	"strings"
+)
+
+// GetBestIcon ranks every discovered icon (link tags, manifest, og:image, root favicon.ico) for the desired
+// size in pixels and returns the best reachable one. A desiredPx of 0 or less selects the largest icon.
+// Icons without declared sizes are downloaded to measure their actual dimensions.
+//
+// Icons are ranked by:
+//  1. size: scalable icons and the smallest icon at least as large as desiredPx first, then the largest of the smaller ones
+//  2. format: SVG > PNG > ICO > other formats
+//  3. purpose: icons meant to be displayed as is before maskable ones
+func (f *Fetcher) GetBestIcon(url string, desiredPx int) (*Icon, error) {
+    icon, _, err := f.bestIcon(url, desiredPx)
+    return icon, err
+}
+
+// bestIcon selects the best icon like GetBestIcon, also returning the image downloaded to measure it
+// (nil if the icon had declared sizes), so it is not downloaded twice
+func (f *Fetcher) bestIcon(url string, desiredPx int) (*Icon, *IconImage, error) {
+    icons, err := f.GetIcons(url)
+    if err != nil {
+        return nil, nil, err
+    }
+    // Browsers fall back to /favicon.ico, so it's always a candidate
+    if parsedUrl, err := Url.Parse(url); err == nil {
+        rootFavicon := parsedUrl.Scheme + "://" + parsedUrl.Host + "/favicon.ico"
+        if !containsIcon(icons, rootFavicon) {
+            icons = append(icons, Icon{URL: rootFavicon, Type: "image/x-icon", Source: IconSourceRoot})
+        }
+    }
+
+    // Icons without declared sizes are downloaded and measured. Icons which cannot be decoded (AVIF, truncated ICO...)
+    // are kept unmeasured, rankIcons places them after the icons with a size.
+    var measured []Icon
+    downloaded := make(map[string]*IconImage)
+    for _, icon := range icons {
+        if len(icon.Sizes) == 0 && !icon.AnySize && iconFormat(icon) != "svg" {
+            iconImage, err := f.GetIconImage(icon.URL)
+            if err != nil {
+                measured = append(measured, icon)
+                continue
+            }
+            icon.Sizes = []IconSize{{Width: iconImage.Width, Height: iconImage.Height}}
+            if icon.Type == "" {
+                icon.Type = iconImage.MIMEType
+            }
+            icon.Measured = true
+            downloaded[icon.URL] = iconImage
+        }
+        measured = append(measured, icon)
+    }
+
+    candidates := rankIcons(measured, desiredPx)
+    for i := range candidates {
+        // measured icons have already been downloaded, unmeasured ones are checked
+        if candidates[i].Measured || isReachable(candidates[i].URL, f) {
+            return &candidates[i], downloaded[candidates[i].URL], nil
+        }
+    }
+    return nil, nil, fmt.Errorf("GetBestIcon failed to find any reachable icons")
+}
+
+// formatPreference orders icon formats, lower is better
+var formatPreference = map[string]int{
+    "svg": 0,
+    "png": 1,
+    "ico": 2,
+}
+
+// rankIcons returns the usable icons sorted from the best to the worst candidate for the desired size
+func rankIcons(icons []Icon, desiredPx int) []Icon {
+    if desiredPx <= 0 {
+        desiredPx = math.MaxInt
+    }
+
+    var candidates []Icon
+    for _, icon := range icons {
+        // monochrome icons (manifest "monochrome" purpose, Safari mask-icon) are meant to be recolored by the platform
+        if len(icon.Purpose) > 0 && !contains(icon.Purpose, "any") && !contains(icon.Purpose, "maskable") {
+            continue
+        }
+        if contains(strings.Fields(icon.Rel), "mask-icon") {
+            continue
+        }
+        candidates = append(candidates, icon)
+    }
+
+    // sizeRank returns the size class of the icon and a value to sort within the class, lower is better
+    sizeRank := func(icon Icon) (int, int) {
+        if icon.AnySize || iconFormat(icon) == "svg" {
+            return 0, 0
+        }
+        px := iconSizePx(icon)
+        switch {
+        case px == 0:
+            return 2, 0
+        case px >= desiredPx:
+            return 0, px - desiredPx
+        default:
+            return 1, desiredPx - px
+        }
+    }
+    formatRank := func(icon Icon) int {
+        if rank, found := formatPreference[iconFormat(icon)]; found {
+            return rank
+        }
+        return len(formatPreference)
+    }
+    purposeRank := func(icon Icon) int {
+        if len(icon.Purpose) > 0 && !contains(icon.Purpose, "any") {
+            return 1
+        }
+        return 0
+    }
+
+    sort.SliceStable(candidates, func(i, j int) bool {
+        classI, distanceI := sizeRank(candidates[i])
+        classJ, distanceJ := sizeRank(candidates[j])
+        if classI != classJ {
+            return classI < classJ
+        }
+        if distanceI != distanceJ {
+            return distanceI < distanceJ
+        }
+        if formatRank(candidates[i]) != formatRank(candidates[j]) {
+            return formatRank(candidates[i]) < formatRank(candidates[j])
+        }
+        return purposeRank(candidates[i]) < purposeRank(candidates[j])
+    })
+    return candidates
+}
+
+// iconSizePx returns the largest declared dimension of the icon, 0 if no size is declared
+func iconSizePx(icon Icon) int {
+    px := 0
+    for _, size := range icon.Sizes {
+        px = max(px, size.Width, size.Height)
+    }
+    return px
+}
+
+// iconFormat returns the image format of the icon ("svg", "png", "ico", ...) based on its declared MIME type,
+// falling back to the file extension of its URL
+func iconFormat(icon Icon) string {
+    mimeType := strings.ToLower(icon.Type)
+    if mimeType == "" && strings.HasPrefix(icon.URL, "data:") {
+        mimeType, _, _ = strings.Cut(strings.TrimPrefix(icon.URL, "data:"), ";")
+        mimeType, _, _ = strings.Cut(mimeType, ",")
+    }
+    switch mimeType {
+    case "image/svg+xml":
+        return "svg"
+    case "image/png":
+        return "png"
+    case "image/x-icon", "image/vnd.microsoft.icon", "image/ico", "image/icon":
+        return "ico"
+    case "image/jpeg", "image/jpg":
+        return "jpeg"
+    case "image/gif":
+        return "gif"
+    case "image/webp":
+        return "webp"
+    }
+    if parsedUrl, err := Url.Parse(icon.URL); err == nil && parsedUrl.Scheme != "data" {
+        ext := strings.ToLower(strings.TrimPrefix(path.Ext(parsedUrl.Path), "."))
+        if ext == "jpg" {
+            ext = "jpeg"
+        }
+        return ext
+    }
+    return ""
+}
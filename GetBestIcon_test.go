@@ -0,0 +1,156 @@
+package katsuragi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetBestIcon(t *testing.T) {
+    head := `<link rel="icon" type="image/png" href="/favicon-16.png" sizes="16x16">
+        <link rel="icon" type="image/png" href="/favicon-32.png" sizes="32x32">
+        <link rel="icon" href="/favicon-64.ico" sizes="64x64">
+        <link rel="icon" type="image/png" href="/favicon-64.png" sizes="64x64">
+        <link rel="apple-touch-icon" href="/apple-touch-icon.png" sizes="180x180">
+        <link rel="icon" href="/missing-256.png" sizes="256x256">`
+
+    tests := []struct {
+        name        string
+        head        string
+        desiredPx   int
+        expectedURL string
+        expectedErr string
+    }{
+        {"Exact Size", head, 32, "/favicon-32.png", ""},
+        {"Smallest Larger Size", head, 48, "/favicon-64.png", ""},
+        {"Larger Than Available", head, 1024, "/apple-touch-icon.png", ""},
+        {"Largest", head, 0, "/apple-touch-icon.png", ""},
+        {"Scalable Icon", head + `<link rel="icon" href="/favicon.svg">`, 32, "/favicon.svg", ""},
+        {"Root Favicon", `<link rel="icon" href="/missing.png">`, 32, "/favicon.ico", ""},
+        {"Measured Size", `<link rel="icon" href="/favicon-16.png" sizes="16x16"><link rel="icon" href="/measured.png">`, 64, "/measured.png", ""},
+        {"Undecodable After Measured", `<link rel="icon" type="image/avif" href="/favicon.avif">`, 32, "/favicon.ico", ""},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+                switch r.URL.Path {
+                case "/":
+                    w.Header().Set("Content-Type", "text/html")
+                    w.Write([]byte("<html><head>" + tt.head + "</head><body></body></html>"))
+                case "/missing.png", "/missing-256.png":
+                    w.WriteHeader(http.StatusNotFound)
+                case "/favicon.ico":
+                    w.Write(testIco(IconSize{16, 16}, IconSize{32, 32}))
+                case "/measured.png":
+                    w.Write(testPng(64, 64))
+                case "/favicon.avif":
+                    w.Write([]byte("not a decodable image"))
+                default:
+                    // HEAD is not supported, GET is used instead
+                    if r.Method == "HEAD" {
+                        w.WriteHeader(http.StatusMethodNotAllowed)
+                    }
+                }
+            }))
+            defer server.Close()
+            f := NewFetcher(&FetcherProps{Timeout: 3000, CacheCap: 10})
+            defer f.ClearCache()
+
+            icon, err := f.GetBestIcon(server.URL, tt.desiredPx)
+            if tt.expectedErr != "" {
+                if err == nil || err.Error() != tt.expectedErr {
+                    t.Fatalf("Expected error %q, got %v", tt.expectedErr, err)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("Expected no error, got: %v", err)
+            }
+            if icon.URL != server.URL+tt.expectedURL {
+                t.Errorf("Expected icon %s, got %s", server.URL+tt.expectedURL, icon.URL)
+            }
+        })
+    }
+}
+
+func TestGetBestIcon_UndecodableIcon(t *testing.T) {
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        switch r.URL.Path {
+        case "/":
+            w.Header().Set("Content-Type", "text/html")
+            w.Write([]byte(`<html><head><link rel="icon" type="image/avif" href="/favicon.avif"></head><body></body></html>`))
+        case "/favicon.avif":
+            w.Write([]byte("not a decodable image"))
+        default:
+            w.WriteHeader(http.StatusNotFound)
+        }
+    }))
+    defer server.Close()
+
+    // the only icon cannot be measured but is reachable
+    icon, err := NewFetcher(nil).GetBestIcon(server.URL, 32)
+    if err != nil {
+        t.Fatalf("Expected no error, got: %v", err)
+    }
+    if icon.URL != server.URL+"/favicon.avif" || icon.Measured {
+        t.Errorf("Expected unmeasured icon %s, got %+v", server.URL+"/favicon.avif", icon)
+    }
+}
+
+func TestGetBestIcon_NoReachableIcons(t *testing.T) {
+    server := MockServer(t, `<html><head><link rel="icon" href="/favicon.png"></head><body></body></html>`)
+    defer server.Close()
+    f := NewFetcher(nil)
+
+    _, err := f.GetBestIcon(server.URL, 32)
+    if err == nil || err.Error() != "GetBestIcon failed to find any reachable icons" {
+        t.Fatalf("Expected error, got %v", err)
+    }
+}
+
+func TestRankIcons(t *testing.T) {
+    icons := []Icon{
+        {URL: "http://example.com/safari-pinned-tab.svg", Rel: "mask-icon"},
+        {URL: "http://example.com/monochrome.png", Sizes: []IconSize{{32, 32}}, Purpose: []string{"monochrome"}},
+        {URL: "http://example.com/maskable.png", Sizes: []IconSize{{32, 32}}, Purpose: []string{"maskable"}},
+        {URL: "http://example.com/favicon.ico", Sizes: []IconSize{{32, 32}}},
+        {URL: "http://example.com/favicon.png", Sizes: []IconSize{{32, 32}}},
+        {URL: "http://example.com/unknown.png"},
+    }
+    expected := []string{
+        "http://example.com/favicon.png",
+        "http://example.com/maskable.png",
+        "http://example.com/favicon.ico",
+        "http://example.com/unknown.png",
+    }
+
+    ranked := rankIcons(icons, 32)
+    if len(ranked) != len(expected) {
+        t.Fatalf("Expected %d icons, got %d: %+v", len(expected), len(ranked), ranked)
+    }
+    for i, url := range expected {
+        if ranked[i].URL != url {
+            t.Errorf("Expected icon %d to be %s, got %s", i, url, ranked[i].URL)
+        }
+    }
+}
+
+func TestIconFormat(t *testing.T) {
+    tests := []struct {
+        icon     Icon
+        expected string
+    }{
+        {Icon{URL: "http://example.com/icon", Type: "image/svg+xml"}, "svg"},
+        {Icon{URL: "http://example.com/icon.PNG"}, "png"},
+        {Icon{URL: "http://example.com/favicon.ico?v=2"}, "ico"},
+        {Icon{URL: "http://example.com/icon.jpg"}, "jpeg"},
+        {Icon{URL: "data:image/png;base64,iVBORw0KGgo="}, "png"},
+        {Icon{URL: "http://example.com/icon"}, ""},
+    }
+    for _, tt := range tests {
+        if format := iconFormat(tt.icon); format != tt.expected {
+            t.Errorf("iconFormat(%+v) = %q; want %q", tt.icon, format, tt.expected)
+        }
+    }
+}
@@ -0,0 +1,203 @@
+package katsuragi
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// GetCanonical fetches the canonical URL of the given URL from the <link rel="canonical"> tag,
+// the HTTP Link header or the og:url meta tag, in this order of precedence
+func (f *Fetcher) GetCanonical(url string) (string, error) {
+    document, err := retrieveDocument(url, f)
+    if err != nil {
+        return "", err
+    }
+    canonical, found := extractCanonical(document.root, document.responseHeader(), url)
+    if !found {
+        return "", fmt.Errorf("GetCanonical failed to find canonical URL in HTML")
+    }
+    return canonical, nil
+}
+
+// Canonical extracts the canonical URL of the document, see GetCanonical
+func (d *Document) Canonical() (string, error) {
+    canonical, found := extractCanonical(d.root, d.responseHeader(), d.url)
+    if !found {
+        return "", fmt.Errorf("Canonical failed to find canonical URL in HTML")
+    }
+    return canonical, nil
+}
+
+// GetAlternates fetches the language and region variants of the given URL declared with
+// <link rel="alternate" hreflang="..."> tags and the HTTP Link header, including "x-default"
+func (f *Fetcher) GetAlternates(url string) ([]Alternate, error) {
+    document, err := retrieveDocument(url, f)
+    if err != nil {
+        return nil, err
+    }
+    alternates := extractAlternates(document.root, document.responseHeader(), url)
+    if len(alternates) == 0 {
+        return nil, fmt.Errorf("GetAlternates failed to find any alternates in HTML")
+    }
+    return alternates, nil
+}
+
+// Alternates extracts the language and region variants of the document, see GetAlternates
+func (d *Document) Alternates() ([]Alternate, error) {
+    alternates := extractAlternates(d.root, d.responseHeader(), d.url)
+    if len(alternates) == 0 {
+        return nil, fmt.Errorf("Alternates failed to find any alternates in HTML")
+    }
+    return alternates, nil
+}
+
+// extractCanonical returns the absolute canonical URL from the <link rel="canonical"> tag,
+// the Link header or the og:url meta tag
+func extractCanonical(doc *html.Node, header http.Header, url string) (string, bool) {
+    var linkTag, ogURL string
+    var traverse func(*html.Node)
+    traverse = func(n *html.Node) {
+        if n.Type == html.ElementNode {
+            attrMap := extractAttributes(n.Attr)
+            switch n.Data {
+            case "link":
+                href := strings.TrimSpace(attrMap["href"])
+                if linkTag == "" && href != "" && contains(strings.Fields(strings.ToLower(attrMap["rel"])), "canonical") {
+                    linkTag = href
+                }
+            case "meta":
+                content := strings.TrimSpace(attrMap["content"])
+                if ogURL == "" && content != "" && strings.EqualFold(attrMap["property"], "og:url") {
+                    ogURL = content
+                }
+            }
+        }
+        for c := n.FirstChild; c != nil; c = c.NextSibling {
+            traverse(c)
+        }
+    }
+    traverse(doc)
+
+    if linkTag != "" {
+        return ensureAbsoluteURL(linkTag, url), true
+    }
+    for _, link := range parseLinkHeader(header) {
+        if contains(strings.Fields(strings.ToLower(link.params["rel"])), "canonical") {
+            return ensureAbsoluteURL(link.url, url), true
+        }
+    }
+    if ogURL != "" {
+        return ensureAbsoluteURL(ogURL, url), true
+    }
+    return "", false
+}
+
+// extractAlternates returns the hreflang alternates of the <link> tags followed by the ones of the Link header,
+// without duplicates
+func extractAlternates(doc *html.Node, header http.Header, url string) []Alternate {
+    var alternates []Alternate
+    seen := make(map[string]bool)
+    add := func(href string, hreflang string, source string) {
+        href, hreflang = strings.TrimSpace(href), strings.TrimSpace(hreflang)
+        if href == "" || hreflang == "" {
+            return
+        }
+        alternate := parseHreflang(hreflang)
+        alternate.URL = ensureAbsoluteURL(href, url)
+        alternate.Source = source
+        key := strings.ToLower(hreflang) + " " + alternate.URL
+        if !seen[key] {
+            seen[key] = true
+            alternates = append(alternates, alternate)
+        }
+    }
+
+    var traverse func(*html.Node)
+    traverse = func(n *html.Node) {
+        if n.Type == html.ElementNode && n.Data == "link" {
+            attrMap := extractAttributes(n.Attr)
+            if contains(strings.Fields(strings.ToLower(attrMap["rel"])), "alternate") {
+                add(attrMap["href"], attrMap["hreflang"], "link")
+            }
+        }
+        for c := n.FirstChild; c != nil; c = c.NextSibling {
+            traverse(c)
+        }
+    }
+    traverse(doc)
+
+    for _, link := range parseLinkHeader(header) {
+        if contains(strings.Fields(strings.ToLower(link.params["rel"])), "alternate") {
+            add(link.url, link.params["hreflang"], "header")
+        }
+    }
+    return alternates
+}
+
+// parseHreflang splits an hreflang value into its language, script and region subtags
+func parseHreflang(hreflang string) Alternate {
+    alternate := Alternate{Hreflang: hreflang}
+    if strings.EqualFold(hreflang, "x-default") {
+        return alternate
+    }
+    for i, subtag := range strings.FieldsFunc(hreflang, func(r rune) bool { return r == '-' || r == '_' }) {
+        switch {
+        case i == 0:
+            alternate.Language = strings.ToLower(subtag)
+        case len(subtag) == 4 && alternate.Script == "" && alternate.Region == "":
+            alternate.Script = strings.ToUpper(subtag[:1]) + strings.ToLower(subtag[1:])
+        case (len(subtag) == 2 || len(subtag) == 3) && alternate.Region == "":
+            alternate.Region = strings.ToUpper(subtag)
+        }
+    }
+    return alternate
+}
+
+// linkHeaderValue is a link of an HTTP Link header
+type linkHeaderValue struct {
+    url    string
+    params map[string]string // lowercase parameter names, unquoted values
+}
+
+// parseLinkHeader parses the links of the HTTP Link headers (RFC 8288), e.g.
+// `<https://www.example.com/de/>; rel="alternate"; hreflang="de", <https://www.example.com/>; rel="canonical"`
+func parseLinkHeader(header http.Header) []linkHeaderValue {
+    var links []linkHeaderValue
+    for _, value := range header.Values("Link") {
+        for value != "" {
+            start := strings.Index(value, "<")
+            end := strings.Index(value, ">")
+            if start == -1 || end < start {
+                break
+            }
+            link := linkHeaderValue{url: strings.TrimSpace(value[start+1 : end]), params: make(map[string]string)}
+            value = value[end+1:]
+
+            // parameters run until the next comma outside of quotes
+            inQuotes := false
+            paramsEnd := len(value)
+            for i, r := range value {
+                if r == '"' {
+                    inQuotes = !inQuotes
+                } else if r == ',' && !inQuotes {
+                    paramsEnd = i
+                    break
+                }
+            }
+            for _, param := range strings.Split(value[:paramsEnd], ";") {
+                name, paramValue, found := strings.Cut(param, "=")
+                if !found {
+                    continue
+                }
+                link.params[strings.ToLower(strings.TrimSpace(name))] = strings.Trim(strings.TrimSpace(paramValue), `"`)
+            }
+            links = append(links, link)
+            value = value[paramsEnd:]
+            value = strings.TrimPrefix(value, ",")
+        }
+    }
+    return links
+}
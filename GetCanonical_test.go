@@ -0,0 +1,124 @@
+package katsuragi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// headServer serves an HTML page with the given head and Link header
+func headServer(t *testing.T, htmlHead string, linkHeader string) *httptest.Server {
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path != "/page" {
+            w.WriteHeader(http.StatusNotFound)
+            return
+        }
+        if linkHeader != "" {
+            w.Header().Set("Link", linkHeader)
+        }
+        w.Header().Set("Content-Type", "text/html")
+        w.Write([]byte("<html><head>" + htmlHead + "</head><body></body></html>"))
+    }))
+    t.Cleanup(server.Close)
+    return server
+}
+
+func TestGetCanonical(t *testing.T) {
+    tests := []struct {
+        name        string
+        htmlHead    string
+        linkHeader  string
+        expected    string // relative to the server URL unless absolute
+        expectedErr string
+    }{
+        {
+            name:       "Link Tag",
+            htmlHead:   `<meta property="og:url" content="/og"><link rel="canonical" href="/canonical">`,
+            linkHeader: `</header>; rel="canonical"`,
+            expected:   "/canonical",
+        },
+        {
+            name:       "Link Header",
+            htmlHead:   `<meta property="og:url" content="/og">`,
+            linkHeader: `<https://cdn.example.com/style.css>; rel=preload, <https://www.example.com/header>; rel="canonical"`,
+            expected:   "https://www.example.com/header",
+        },
+        {
+            name:     "og:url",
+            htmlHead: `<meta property="og:url" content="https://www.example.com/og">`,
+            expected: "https://www.example.com/og",
+        },
+        {
+            name:        "No Canonical",
+            htmlHead:    `<title>Page</title>`,
+            expectedErr: "GetCanonical failed to find canonical URL in HTML",
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            server := headServer(t, tt.htmlHead, tt.linkHeader)
+            canonical, err := NewFetcher(nil).GetCanonical(server.URL + "/page")
+            if tt.expectedErr != "" {
+                if err == nil || err.Error() != tt.expectedErr {
+                    t.Errorf("GetCanonical() error = %v, want %v", err, tt.expectedErr)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("GetCanonical() error = %v", err)
+            }
+            expected := tt.expected
+            if expected[0] == '/' {
+                expected = server.URL + expected
+            }
+            if canonical != expected {
+                t.Errorf("GetCanonical() = %v, want %v", canonical, expected)
+            }
+        })
+    }
+}
+
+func TestGetAlternates(t *testing.T) {
+    server := headServer(t, `
+        <link rel="alternate" hreflang="en-gb" href="/en-gb/">
+        <link rel="alternate" hreflang="zh-Hant-TW" href="https://www.example.com/zh-tw/">
+        <link rel="alternate" hreflang="x-default" href="/">
+        <link rel="alternate" hreflang="en-gb" href="/en-gb/">
+        <link rel="alternate" type="application/rss+xml" href="/feed.xml">`,
+        `<https://www.example.com/de/>; rel="alternate"; hreflang="de", <https://www.example.com/>; rel=canonical`)
+
+    alternates, err := NewFetcher(nil).GetAlternates(server.URL + "/page")
+    if err != nil {
+        t.Fatalf("GetAlternates() error = %v", err)
+    }
+    expected := []Alternate{
+        {URL: server.URL + "/en-gb/", Hreflang: "en-gb", Language: "en", Region: "GB", Source: "link"},
+        {URL: "https://www.example.com/zh-tw/", Hreflang: "zh-Hant-TW", Language: "zh", Script: "Hant", Region: "TW", Source: "link"},
+        {URL: server.URL + "/", Hreflang: "x-default", Source: "link"},
+        {URL: "https://www.example.com/de/", Hreflang: "de", Language: "de", Source: "header"},
+    }
+    if !reflect.DeepEqual(alternates, expected) {
+        t.Errorf("GetAlternates() = %+v, want %+v", alternates, expected)
+    }
+
+    empty := headServer(t, `<link rel="canonical" href="/page">`, "")
+    if _, err := NewFetcher(nil).GetAlternates(empty.URL + "/page"); err == nil || err.Error() != "GetAlternates failed to find any alternates in HTML" {
+        t.Errorf("expected an error without alternates, got %v", err)
+    }
+}
+
+func TestParseLinkHeader(t *testing.T) {
+    header := http.Header{}
+    header.Add("Link", `<https://www.example.com/a,b>; rel="alternate"; hreflang=de; title="a, b", </relative>; REL=canonical`)
+    header.Add("Link", `invalid`)
+    links := parseLinkHeader(header)
+    expected := []linkHeaderValue{
+        {url: "https://www.example.com/a,b", params: map[string]string{"rel": "alternate", "hreflang": "de", "title": "a, b"}},
+        {url: "/relative", params: map[string]string{"rel": "canonical"}},
+    }
+    if !reflect.DeepEqual(links, expected) {
+        t.Errorf("parseLinkHeader() = %+v, want %+v", links, expected)
+    }
+}
@@ -0,0 +1,105 @@
+package katsuragi
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// GetEmails fetches the email addresses of the mailto: links of the given URL
+func (f *Fetcher) GetEmails(url string) ([]string, error) {
+    doc, err := retrieveHTML(url, f)
+    if err != nil {
+        return nil, err
+    }
+    emails := extractEmails(doc, url)
+    if len(emails) == 0 {
+        return nil, fmt.Errorf("GetEmails failed to find any email addresses in HTML")
+    }
+    return emails, nil
+}
+
+// GetPhoneNumbers fetches the phone numbers of the tel: links of the given URL
+func (f *Fetcher) GetPhoneNumbers(url string) ([]string, error) {
+    doc, err := retrieveHTML(url, f)
+    if err != nil {
+        return nil, err
+    }
+    phoneNumbers := extractPhoneNumbers(doc, url)
+    if len(phoneNumbers) == 0 {
+        return nil, fmt.Errorf("GetPhoneNumbers failed to find any phone numbers in HTML")
+    }
+    return phoneNumbers, nil
+}
+
+// Emails extracts the email addresses of the document, see GetEmails
+func (d *Document) Emails() ([]string, error) {
+    emails := extractEmails(d.root, d.url)
+    if len(emails) == 0 {
+        return nil, fmt.Errorf("Emails failed to find any email addresses in HTML")
+    }
+    return emails, nil
+}
+
+// PhoneNumbers extracts the phone numbers of the document, see GetPhoneNumbers
+func (d *Document) PhoneNumbers() ([]string, error) {
+    phoneNumbers := extractPhoneNumbers(d.root, d.url)
+    if len(phoneNumbers) == 0 {
+        return nil, fmt.Errorf("PhoneNumbers failed to find any phone numbers in HTML")
+    }
+    return phoneNumbers, nil
+}
+
+// extractEmails collects the unique addresses of the mailto: links, including multiple recipients
+// (mailto:a@example.com,b@example.com) and the `to`, `cc` and `bcc` fields
+func extractEmails(doc *html.Node, pageUrl string) []string {
+    var emails []string
+    seen := make(map[string]bool)
+    for _, link := range extractLinkDetails(doc, GetLinksProps{Url: pageUrl, Category: LinkCategoryAll, Kinds: []LinkKind{LinkKindEmail}}) {
+        parsedUrl, err := url.Parse(link.URL)
+        if err != nil {
+            continue
+        }
+        recipients := []string{parsedUrl.Opaque}
+        query := parsedUrl.Query()
+        for _, field := range []string{"to", "cc", "bcc"} {
+            recipients = append(recipients, query[field]...)
+        }
+        for _, recipient := range recipients {
+            if unescaped, err := url.PathUnescape(recipient); err == nil {
+                recipient = unescaped
+            }
+            for _, email := range strings.Split(recipient, ",") {
+                email = strings.TrimSpace(email)
+                if !strings.Contains(email, "@") || seen[strings.ToLower(email)] {
+                    continue
+                }
+                seen[strings.ToLower(email)] = true
+                emails = append(emails, email)
+            }
+        }
+    }
+    return emails
+}
+
+// extractPhoneNumbers collects the unique phone numbers of the tel: links, as declared
+func extractPhoneNumbers(doc *html.Node, pageUrl string) []string {
+    var phoneNumbers []string
+    for _, link := range extractLinkDetails(doc, GetLinksProps{Url: pageUrl, Category: LinkCategoryAll, Kinds: []LinkKind{LinkKindPhone}}) {
+        parsedUrl, err := url.Parse(link.URL)
+        if err != nil {
+            continue
+        }
+        phoneNumber := parsedUrl.Opaque
+        if unescaped, err := url.PathUnescape(phoneNumber); err == nil {
+            phoneNumber = unescaped
+        }
+        phoneNumber = strings.TrimSpace(phoneNumber)
+        if phoneNumber != "" && !contains(phoneNumbers, phoneNumber) {
+            phoneNumbers = append(phoneNumbers, phoneNumber)
+        }
+    }
+    return phoneNumbers
+}
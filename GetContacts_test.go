@@ -0,0 +1,64 @@
+package katsuragi
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testContactsPage = `<html><body>
+    <a href="mailto:info@example.com">Mail us</a>
+    <a href="MAILTO:Sales@Example.com,support@example.com?subject=Hello&cc=cc%40example.com">Sales</a>
+    <a href="mailto:info@example.com?subject=Again">Mail us again</a>
+    <a href="mailto:?subject=Share">Share</a>
+    <a href="tel:+1-555-0100">Call</a>
+    <a href="tel:+1%20555%200199">Call</a>
+    <a href="tel:+1-555-0100">Call again</a>
+    <a href="/contact">Contact</a>
+    </body></html>`
+
+func TestGetEmailsAndPhoneNumbers(t *testing.T) {
+    server := MockServer(t, testContactsPage)
+    defer server.Close()
+    f := NewFetcher(&FetcherProps{Timeout: 3000, CacheCap: 10})
+    defer f.ClearCache()
+
+    emails, err := f.GetEmails(server.URL)
+    if err != nil {
+        t.Fatalf("Expected no error, got: %v", err)
+    }
+    expectedEmails := []string{"info@example.com", "Sales@Example.com", "support@example.com", "cc@example.com"}
+    if !reflect.DeepEqual(emails, expectedEmails) {
+        t.Errorf("Expected emails %v, got %v", expectedEmails, emails)
+    }
+
+    phoneNumbers, err := f.GetPhoneNumbers(server.URL)
+    if err != nil {
+        t.Fatalf("Expected no error, got: %v", err)
+    }
+    expectedPhoneNumbers := []string{"+1-555-0100", "+1 555 0199"}
+    if !reflect.DeepEqual(phoneNumbers, expectedPhoneNumbers) {
+        t.Errorf("Expected phone numbers %v, got %v", expectedPhoneNumbers, phoneNumbers)
+    }
+}
+
+func TestGetEmailsAndPhoneNumbers_NotFound(t *testing.T) {
+    server := MockServer(t, `<html><body><a href="/contact">Contact</a></body></html>`)
+    defer server.Close()
+    f := NewFetcher(&FetcherProps{Timeout: 3000, CacheCap: 10})
+    defer f.ClearCache()
+
+    if _, err := f.GetEmails(server.URL); err == nil || err.Error() != "GetEmails failed to find any email addresses in HTML" {
+        t.Errorf("Expected error, got %v", err)
+    }
+    if _, err := f.GetPhoneNumbers(server.URL); err == nil || err.Error() != "GetPhoneNumbers failed to find any phone numbers in HTML" {
+        t.Errorf("Expected error, got %v", err)
+    }
+
+    doc, _ := ParseString(testContactsPage, "http://example.com")
+    if emails, err := doc.Emails(); err != nil || len(emails) != 4 {
+        t.Errorf("Expected 4 emails, got %v (%v)", emails, err)
+    }
+    if phoneNumbers, err := doc.PhoneNumbers(); err != nil || len(phoneNumbers) != 2 {
+        t.Errorf("Expected 2 phone numbers, got %v (%v)", phoneNumbers, err)
+    }
+}
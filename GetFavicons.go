@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	Url "net/url"
+	"strings"
 
 	"golang.org/x/net/html"
 )
@@ -14,6 +15,13 @@ func (f *Fetcher) GetFavicons(url string) ([]string, error) {
         return nil, err
     }
     favicons, found := traverseAndExtractFavicons(htmlDoc, url)
+    // PWAs often declare their icons only in the manifest, Windows tiles in browserconfig.xml
+    for _, icon := range append(getManifestIcons(htmlDoc, url, f), getBrowserConfigIcons(htmlDoc, url, f)...) {
+        if !contains(favicons, icon.URL) {
+            favicons = append(favicons, icon.URL)
+            found = true
+        }
+    }
     if !found {
         getRootFaviconIco(&favicons, url)
     }
@@ -26,14 +34,30 @@ func (f *Fetcher) GetFavicons(url string) ([]string, error) {
 
 // valid tags for favicons
 var validRel = map[string]bool{
-    "icon":                  true,
-    "apple-touch-icon":      true,
-    "shortcut icon":         true,
+    "icon":                         true,
+    "apple-touch-icon":             true,
+    "apple-touch-icon-precomposed": true,
+    "shortcut icon":                true,
+    "alternate icon":               true,
+    "fluid-icon":                   true, // GitHub's Fluid app icon
+    "mask-icon":                    true, // Safari pinned tab, monochrome SVG
+}
 
-    // to be reviewed:
-    // "fluid-icon":            true,
-    // "mask-icon":             true,
-    // "alternate icon":        true,
+// isIconRel checks if a `rel` attribute declares an icon. The check is case-insensitive and
+// multi-token values (e.g. "Shortcut Icon" or "icon apple-touch-icon") match if any token is an icon rel.
+// The normalized (lowercase, single-spaced) rel is returned.
+func isIconRel(rel string) (string, bool) {
+    tokens := strings.Fields(strings.ToLower(rel))
+    normalized := strings.Join(tokens, " ")
+    if validRel[normalized] {
+        return normalized, true
+    }
+    for _, token := range tokens {
+        if validRel[token] {
+            return normalized, true
+        }
+    }
+    return normalized, false
 }
 var validMeta = map[string]bool{
     "og:image":          true,
@@ -74,7 +98,7 @@ func traverseAndExtractFavicons(n *html.Node, url string) ([]string, bool) {
     if n.Type == html.ElementNode && (n.Data == "link" || n.Data == "meta") && n.Parent.Data == "head" {
         attrMap := extractAttributes(n.Attr)
         if n.Data == "link" {
-            if rel, found := attrMap["rel"]; found && validRel[rel] {
+            if _, isIcon := isIconRel(attrMap["rel"]); isIcon {
                 if href, found := attrMap["href"]; found {
                     if !contains(favicons, href) {
                         favicons = append(favicons, href)
@@ -82,6 +106,11 @@ func traverseAndExtractFavicons(n *html.Node, url string) ([]string, bool) {
 
                 }
             }
+        // Windows tile image
+        } else if name, found := attrMap["name"]; found && strings.EqualFold(name, "msapplication-TileImage") {
+            if content, found := attrMap["content"]; found && content != "" && !contains(favicons, content) {
+                favicons = append(favicons, content)
+            }
         // og:image + aspect ratio check
         } else if n.Data == "meta" {
             if property, found := attrMap["property"]; found && validMeta[property] {
@@ -196,4 +196,47 @@ func TestGetRootFaviconIco(t *testing.T) {
             }
         })
     }
-}
\ No newline at end of file
+}
+
+func TestGetFavicons_IconRels(t *testing.T) {
+    server := MockServer(t, `<html><head>
+        <link rel="Shortcut Icon" href="/favicon.ico">
+        <link rel="icon apple-touch-icon" href="/apple-touch-icon.png">
+        <link rel="mask-icon" href="/safari-pinned-tab.svg" color="#5bbad5">
+        <meta name="msapplication-TileImage" content="/mstile-144x144.png">
+        </head><body></body></html>`)
+    defer server.Close()
+
+    favicons, err := NewFetcher(nil).GetFavicons(server.URL)
+    if err != nil {
+        t.Fatalf("Expected no error, got: %v", err)
+    }
+    if len(favicons) != 4 {
+        t.Fatalf("Expected 4 favicons, found %d", len(favicons))
+    }
+}
+
+func TestIsIconRel(t *testing.T) {
+    tests := []struct {
+        rel                string
+        expectedNormalized string
+        expectedIsIcon     bool
+    }{
+        {"icon", "icon", true},
+        {"Shortcut Icon", "shortcut icon", true},
+        {"icon apple-touch-icon", "icon apple-touch-icon", true},
+        {"  alternate   icon ", "alternate icon", true},
+        {"mask-icon", "mask-icon", true},
+        {"fluid-icon", "fluid-icon", true},
+        {"APPLE-TOUCH-ICON-PRECOMPOSED", "apple-touch-icon-precomposed", true},
+        {"stylesheet", "stylesheet", false},
+        {"manifest", "manifest", false},
+        {"", "", false},
+    }
+    for _, tt := range tests {
+        normalized, isIcon := isIconRel(tt.rel)
+        if normalized != tt.expectedNormalized || isIcon != tt.expectedIsIcon {
+            t.Errorf("isIconRel(%q) = %q, %v; want %q, %v", tt.rel, normalized, isIcon, tt.expectedNormalized, tt.expectedIsIcon)
+        }
+    }
+}
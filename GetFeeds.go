@@ -0,0 +1,504 @@
+package katsuragi
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	Url "net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"golang.org/x/net/html/charset"
+)
+
+// feedLinkTypes maps the MIME types of <link rel="alternate"> tags to feed types
+var feedLinkTypes = map[string]FeedType{
+    "application/rss+xml":   FeedTypeRSS,
+    "application/rdf+xml":   FeedTypeRSS,
+    "application/atom+xml":  FeedTypeAtom,
+    "application/feed+json": FeedTypeJSON,
+}
+
+// plainJSONLinkType is used by JSON Feeds, but also by APIs (e.g. the WordPress REST API links every page
+// to its JSON representation), so these links are only feeds if their version is a JSON Feed version
+const plainJSONLinkType = "application/json"
+
+// commonFeedPaths are probed when a page does not link to any feed
+var commonFeedPaths = []string{
+    "/feed",
+    "/rss",
+    "/feed.xml",
+    "/rss.xml",
+    "/atom.xml",
+    "/index.xml",
+    "/feed.json",
+}
+
+// GetFeeds fetches the feeds linked by the given URL with <link rel="alternate"> tags (RSS, Atom and JSON Feed).
+// If the page links to no feed, common feed paths of the site are probed (see GetFeedsProps.NoProbe).
+// With GetFeedsProps.Parse, every feed is fetched and parsed, errors being reported in Feed.Err.
+func (f *Fetcher) GetFeeds(url string, props GetFeedsProps) ([]Feed, error) {
+    htmlDoc, err := retrieveHTML(url, f)
+    if err != nil {
+        return nil, err
+    }
+    linkedFeeds, unverified := traverseAndExtractFeeds(htmlDoc, url)
+
+    var feeds []Feed
+    for _, feed := range linkedFeeds {
+        if unverified[feed.URL] {
+            // plain JSON links are fetched to check that they are JSON Feeds
+            verified, ok := verifyJSONFeed(feed, f)
+            if !ok {
+                continue
+            }
+            if props.Parse {
+                feed = verified
+            }
+        } else if props.Parse {
+            feed = fetchFeed(feed, f)
+        }
+        feeds = append(feeds, feed)
+    }
+
+    if len(feeds) == 0 && !props.NoProbe {
+        feeds = probeFeeds(url, f, props.Parse)
+    }
+
+    if len(feeds) == 0 {
+        return nil, fmt.Errorf("GetFeeds failed to find any feeds in HTML")
+    }
+    return feeds, nil
+}
+
+// fetchFeed fetches and parses the feed, keeping the discovered feed with the error on failure
+func fetchFeed(feed Feed, f *Fetcher) Feed {
+    data, _, err := fetchResource(feed.URL, f)
+    if err != nil {
+        feed.Err = err
+        return feed
+    }
+    parsed, err := ParseFeed(data, feed.URL)
+    if err != nil {
+        feed.Err = err
+        return feed
+    }
+    if parsed.Title == "" {
+        parsed.Title = feed.Title
+    }
+    return *parsed
+}
+
+// verifyJSONFeed fetches a feed linked as plain JSON and returns it parsed if it is a JSON Feed
+func verifyJSONFeed(feed Feed, f *Fetcher) (Feed, bool) {
+    data, _, err := fetchResource(feed.URL, f)
+    if err != nil {
+        return feed, false
+    }
+    parsed, err := parseJSONFeed(bytes.TrimSpace(data), feed.URL)
+    if err != nil {
+        return feed, false
+    }
+    if parsed.Title == "" {
+        parsed.Title = feed.Title
+    }
+    return *parsed, true
+}
+
+// probeFeeds fetches the common feed paths of the site and keeps the ones which parse as feeds
+func probeFeeds(url string, f *Fetcher, parse bool) []Feed {
+    parsedUrl, err := Url.Parse(url)
+    if err != nil {
+        return nil
+    }
+    var feeds []Feed
+    for _, path := range commonFeedPaths {
+        feedURL := parsedUrl.Scheme + "://" + parsedUrl.Host + path
+        data, _, err := fetchResource(feedURL, f)
+        if err != nil {
+            continue
+        }
+        feed, err := ParseFeed(data, feedURL)
+        if err != nil {
+            continue
+        }
+        if !parse {
+            feed.Description = ""
+            feed.Link = ""
+            feed.Items = nil
+        }
+        feeds = append(feeds, *feed)
+        // paths such as /feed and /feed.xml usually serve the same feed
+        break
+    }
+    return feeds
+}
+
+// traverseAndExtractFeeds traverses the HTML node tree and extracts the feeds of <link rel="alternate"> tags.
+// The URLs of feeds linked as plain JSON are returned as unverified, see plainJSONLinkType.
+func traverseAndExtractFeeds(n *html.Node, url string) ([]Feed, map[string]bool) {
+    var feeds []Feed
+    seen := make(map[string]bool)
+    unverified := make(map[string]bool)
+    var traverse func(*html.Node)
+    traverse = func(n *html.Node) {
+        if n.Type == html.ElementNode && n.Data == "link" {
+            attrMap := extractAttributes(n.Attr)
+            linkType := strings.ToLower(strings.TrimSpace(attrMap["type"]))
+            feedType, isFeed := feedLinkTypes[linkType]
+            if linkType == plainJSONLinkType {
+                feedType, isFeed = FeedTypeJSON, true
+            }
+            rel := strings.Fields(strings.ToLower(attrMap["rel"]))
+            if isFeed && contains(rel, "alternate") && strings.TrimSpace(attrMap["href"]) != "" {
+                feedURL := ensureAbsoluteURL(strings.TrimSpace(attrMap["href"]), url)
+                if !seen[feedURL] {
+                    seen[feedURL] = true
+                    feeds = append(feeds, Feed{URL: feedURL, Type: feedType, Title: strings.TrimSpace(attrMap["title"])})
+                    if linkType == plainJSONLinkType {
+                        unverified[feedURL] = true
+                    }
+                }
+            }
+        }
+        for c := n.FirstChild; c != nil; c = c.NextSibling {
+            traverse(c)
+        }
+    }
+    traverse(n)
+    return feeds, unverified
+}
+
+// ParseFeed parses an RSS (0.9x, 1.0 and 2.0), Atom or JSON feed, detecting its format.
+// Relative links are resolved against feedURL.
+func ParseFeed(data []byte, feedURL string) (*Feed, error) {
+    trimmed := bytes.TrimSpace(data)
+    if bytes.HasPrefix(trimmed, []byte("{")) {
+        return parseJSONFeed(trimmed, feedURL)
+    }
+
+    // namespaces are ignored, so <dc:creator> matches "creator" and <content:encoded> matches "encoded"
+    type rssItem struct {
+        Title       string    `xml:"title"`
+        Links       []rssLink `xml:"link"`
+        GUID        string    `xml:"guid"`
+        PubDate     string    `xml:"pubDate"`
+        Date        string    `xml:"date"`
+        Author      string    `xml:"author"`
+        Creator     string    `xml:"creator"`
+        Description string    `xml:"description"`
+        Encoded     string    `xml:"encoded"`
+    }
+    type atomText struct {
+        Type  string `xml:"type,attr"`
+        Value string `xml:",innerxml"`
+    }
+    var raw struct {
+        XMLName xml.Name
+        // RSS 2.0
+        Channel struct {
+            Title       string    `xml:"title"`
+            Links       []rssLink `xml:"link"`
+            Description string    `xml:"description"`
+            Items       []rssItem `xml:"item"`
+        } `xml:"channel"`
+        // RSS 1.0 items are siblings of the channel
+        Items []rssItem `xml:"item"`
+        // Atom
+        Title    atomText   `xml:"title"`
+        Subtitle atomText   `xml:"subtitle"`
+        Links    []atomLink `xml:"link"`
+        Entries  []struct {
+            ID        string     `xml:"id"`
+            Title     atomText   `xml:"title"`
+            Links     []atomLink `xml:"link"`
+            Published string     `xml:"published"`
+            Updated   string     `xml:"updated"`
+            Author    struct {
+                Name string `xml:"name"`
+            } `xml:"author"`
+            Summary atomText `xml:"summary"`
+            Content atomText `xml:"content"`
+        } `xml:"entry"`
+    }
+    decoder := xml.NewDecoder(bytes.NewReader(trimmed))
+    // feeds are often served with HTML entities and non UTF-8 charsets
+    decoder.Strict = false
+    decoder.Entity = xml.HTMLEntity
+    decoder.CharsetReader = charset.NewReaderLabel
+    if err := decoder.Decode(&raw); err != nil {
+        return nil, fmt.Errorf("ParseFeed failed to parse feed: %v", err)
+    }
+
+    feed := &Feed{URL: feedURL}
+    switch strings.ToLower(raw.XMLName.Local) {
+    case "rss", "rdf":
+        feed.Type = FeedTypeRSS
+        feed.Title = strings.TrimSpace(raw.Channel.Title)
+        feed.Description = htmlToText(raw.Channel.Description)
+        if link := rssLinkValue(raw.Channel.Links); link != "" {
+            feed.Link = ensureAbsoluteURL(link, feedURL)
+        }
+        for _, rawItem := range append(raw.Channel.Items, raw.Items...) {
+            item := FeedItem{
+                ID:        strings.TrimSpace(rawItem.GUID),
+                Title:     strings.TrimSpace(rawItem.Title),
+                Published: parseFeedDate(rawItem.PubDate),
+                Author:    strings.TrimSpace(rawItem.Author),
+                Summary:   htmlToText(rawItem.Description),
+            }
+            if item.Published.IsZero() {
+                item.Published = parseFeedDate(rawItem.Date)
+            }
+            if item.Author == "" {
+                item.Author = strings.TrimSpace(rawItem.Creator)
+            }
+            if item.Summary == "" {
+                item.Summary = htmlToText(rawItem.Encoded)
+            }
+            if link := rssLinkValue(rawItem.Links); link != "" {
+                item.Link = ensureAbsoluteURL(link, feedURL)
+            }
+            if item.ID == "" {
+                item.ID = item.Link
+            }
+            feed.Items = append(feed.Items, item)
+        }
+    case "feed":
+        feed.Type = FeedTypeAtom
+        feed.Title = atomTextValue(raw.Title.Type, raw.Title.Value)
+        feed.Description = atomTextValue(raw.Subtitle.Type, raw.Subtitle.Value)
+        feed.Link = atomAlternateLink(raw.Links, feedURL)
+        for _, entry := range raw.Entries {
+            item := FeedItem{
+                ID:        strings.TrimSpace(entry.ID),
+                Title:     atomTextValue(entry.Title.Type, entry.Title.Value),
+                Link:      atomAlternateLink(entry.Links, feedURL),
+                Published: parseFeedDate(entry.Published),
+                Updated:   parseFeedDate(entry.Updated),
+                Author:    strings.TrimSpace(entry.Author.Name),
+                Summary:   atomTextValue(entry.Summary.Type, entry.Summary.Value),
+            }
+            if item.Summary == "" {
+                item.Summary = atomTextValue(entry.Content.Type, entry.Content.Value)
+            }
+            // Atom entries without a published date only have their updated date
+            if item.Published.IsZero() {
+                item.Published = item.Updated
+            }
+            feed.Items = append(feed.Items, item)
+        }
+    default:
+        return nil, fmt.Errorf("ParseFeed failed to parse feed. Unexpected root element: %v", raw.XMLName.Local)
+    }
+    return feed, nil
+}
+
+// parseJSONFeed parses a JSON Feed (version 1 and 1.1)
+func parseJSONFeed(data []byte, feedURL string) (*Feed, error) {
+    type jsonAuthor struct {
+        Name string `json:"name"`
+    }
+    var raw struct {
+        Version     string `json:"version"`
+        Title       string `json:"title"`
+        HomePageURL string `json:"home_page_url"`
+        Description string `json:"description"`
+        Items       []struct {
+            ID            json.RawMessage `json:"id"`
+            URL           string          `json:"url"`
+            Title         string          `json:"title"`
+            ContentText   string          `json:"content_text"`
+            ContentHTML   string          `json:"content_html"`
+            Summary       string          `json:"summary"`
+            DatePublished string          `json:"date_published"`
+            DateModified  string          `json:"date_modified"`
+            Author        *jsonAuthor     `json:"author"`
+            Authors       []jsonAuthor    `json:"authors"`
+        } `json:"items"`
+    }
+    if err := json.Unmarshal(data, &raw); err != nil {
+        return nil, fmt.Errorf("ParseFeed failed to parse feed: %v", err)
+    }
+    if !strings.HasPrefix(raw.Version, "https://jsonfeed.org/version/") {
+        return nil, fmt.Errorf("ParseFeed failed to parse feed. Unexpected JSON Feed version: %v", raw.Version)
+    }
+
+    feed := &Feed{
+        URL:         feedURL,
+        Type:        FeedTypeJSON,
+        Title:       strings.TrimSpace(raw.Title),
+        Description: strings.TrimSpace(raw.Description),
+    }
+    if raw.HomePageURL != "" {
+        feed.Link = ensureAbsoluteURL(raw.HomePageURL, feedURL)
+    }
+    for _, rawItem := range raw.Items {
+        // ids should be strings, but numbers are common
+        var id string
+        if err := json.Unmarshal(rawItem.ID, &id); err != nil {
+            id = string(rawItem.ID)
+        }
+        item := FeedItem{
+            ID:        strings.TrimSpace(id),
+            Title:     strings.TrimSpace(rawItem.Title),
+            Published: parseFeedDate(rawItem.DatePublished),
+            Updated:   parseFeedDate(rawItem.DateModified),
+            Summary:   strings.TrimSpace(rawItem.Summary),
+        }
+        if rawItem.URL != "" {
+            item.Link = ensureAbsoluteURL(rawItem.URL, feedURL)
+        }
+        if item.Summary == "" {
+            item.Summary = strings.TrimSpace(rawItem.ContentText)
+        }
+        if item.Summary == "" {
+            item.Summary = htmlToText(rawItem.ContentHTML)
+        }
+        if len(rawItem.Authors) > 0 {
+            item.Author = strings.TrimSpace(rawItem.Authors[0].Name)
+        } else if rawItem.Author != nil {
+            item.Author = strings.TrimSpace(rawItem.Author.Name)
+        }
+        feed.Items = append(feed.Items, item)
+    }
+    return feed, nil
+}
+
+// rssLink is a <link> of an RSS channel or item. Namespaces are ignored by the field names, so the <atom:link rel="self">
+// of RSS 2.0 feeds is decoded along with it and told apart by its namespace.
+type rssLink struct {
+    XMLName xml.Name
+    Value   string `xml:",chardata"`
+}
+
+// atomNamespace is the namespace of Atom elements, used as atom:link in RSS feeds
+const atomNamespace = "http://www.w3.org/2005/Atom"
+
+// rssLinkValue returns the first non-empty link of an RSS channel or item which is not an Atom link
+func rssLinkValue(links []rssLink) string {
+    for _, link := range links {
+        // without xmlns:atom declaration, the prefix is kept as namespace
+        if link.XMLName.Space == atomNamespace || link.XMLName.Space == "atom" {
+            continue
+        }
+        if value := strings.TrimSpace(link.Value); value != "" {
+            return value
+        }
+    }
+    return ""
+}
+
+type atomLink struct {
+    Href string `xml:"href,attr"`
+    Rel  string `xml:"rel,attr"`
+}
+
+// atomAlternateLink returns the resolved href of the alternate link (the default rel) of an Atom feed or entry
+func atomAlternateLink(links []atomLink, feedURL string) string {
+    for _, link := range links {
+        if (link.Rel == "" || link.Rel == "alternate") && strings.TrimSpace(link.Href) != "" {
+            return ensureAbsoluteURL(strings.TrimSpace(link.Href), feedURL)
+        }
+    }
+    return ""
+}
+
+// atomTextValue returns the plain text of an Atom text construct, which is escaped HTML or XHTML markup
+// for the "html" and "xhtml" types
+func atomTextValue(textType string, value string) string {
+    if textType == "xhtml" {
+        return htmlToText(value)
+    }
+    // the inner XML of text and html constructs is escaped
+    unescaped := html.UnescapeString(strings.TrimSpace(unwrapCDATA(value)))
+    if textType == "html" {
+        return htmlToText(unescaped)
+    }
+    return strings.Join(strings.Fields(unescaped), " ")
+}
+
+// unwrapCDATA removes the CDATA section markers of inner XML
+func unwrapCDATA(value string) string {
+    value = strings.TrimSpace(value)
+    if strings.HasPrefix(value, "<![CDATA[") && strings.HasSuffix(value, "]]>") {
+        return value[len("<![CDATA[") : len(value)-len("]]>")]
+    }
+    return value
+}
+
+// feedDateLayouts are the date formats found in feeds: RFC 822 variants for RSS, RFC 3339 for Atom and JSON Feed
+var feedDateLayouts = []string{
+    time.RFC1123Z,
+    time.RFC1123,
+    "Mon, 2 Jan 2006 15:04:05 -0700",
+    "Mon, 2 Jan 2006 15:04:05 MST",
+    "Mon, 2 Jan 2006 15:04 -0700",
+    "2 Jan 2006 15:04:05 -0700",
+    "2 Jan 2006 15:04:05 MST",
+    time.RFC822Z,
+    time.RFC822,
+}
+
+// parseFeedDate parses the date of a feed, returning the zero time if the value is missing or invalid
+func parseFeedDate(value string) time.Time {
+    value = strings.TrimSpace(value)
+    if value == "" {
+        return time.Time{}
+    }
+    for _, layout := range feedDateLayouts {
+        if parsed, err := time.Parse(layout, value); err == nil {
+            return fixZoneOffset(parsed)
+        }
+    }
+    return parseW3CDatetime(value)
+}
+
+// usZoneOffsets are the offsets of the US zone abbreviations of RFC 822 (e.g. "Mon, 02 Jan 2006 15:04:05 PST").
+// time.Parse gives abbreviations unknown to the local zone a zero offset.
+var usZoneOffsets = map[string]int{
+    "EST": -5 * 3600, "EDT": -4 * 3600,
+    "CST": -6 * 3600, "CDT": -5 * 3600,
+    "MST": -7 * 3600, "MDT": -6 * 3600,
+    "PST": -8 * 3600, "PDT": -7 * 3600,
+}
+
+// fixZoneOffset gives a time parsed with a US zone abbreviation its fixed offset, which does not depend on the local zone
+func fixZoneOffset(parsed time.Time) time.Time {
+    if name, offset := parsed.Zone(); usZoneOffsets[name] != 0 && offset != usZoneOffsets[name] {
+        return time.Date(parsed.Year(), parsed.Month(), parsed.Day(), parsed.Hour(), parsed.Minute(), parsed.Second(),
+            parsed.Nanosecond(), time.FixedZone(name, usZoneOffsets[name]))
+    }
+    return parsed
+}
+
+// htmlToText returns the text of an HTML fragment with collapsed whitespace
+func htmlToText(fragment string) string {
+    if !strings.Contains(fragment, "<") {
+        return strings.Join(strings.Fields(html.UnescapeString(fragment)), " ")
+    }
+    nodes, err := html.ParseFragment(strings.NewReader(fragment), &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body})
+    if err != nil {
+        return ""
+    }
+    var text []string
+    var collect func(*html.Node)
+    collect = func(n *html.Node) {
+        if n.Type == html.ElementNode && (n.Data == "script" || n.Data == "style") {
+            return
+        }
+        if n.Type == html.TextNode {
+            text = append(text, n.Data)
+        }
+        for c := n.FirstChild; c != nil; c = c.NextSibling {
+            collect(c)
+        }
+    }
+    for _, node := range nodes {
+        collect(node)
+    }
+    return strings.Join(strings.Fields(strings.Join(text, " ")), " ")
+}
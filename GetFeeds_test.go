@@ -0,0 +1,255 @@
+package katsuragi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testRSS = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:content="http://purl.org/rss/1.0/modules/content/" xmlns:atom="http://www.w3.org/2005/Atom">
+<channel>
+  <title>Example Blog</title>
+  <link>https://www.example.com/</link>
+  <atom:link href="https://www.example.com/feed.xml" rel="self" type="application/rss+xml"/>
+  <description>News &amp; updates</description>
+  <item>
+    <title>First post</title>
+    <link>/posts/first</link>
+    <guid isPermaLink="false">post-1</guid>
+    <pubDate>Wed, 01 May 2024 10:00:00 +0000</pubDate>
+    <dc:creator>Jane Doe</dc:creator>
+    <description><![CDATA[<p>Hello <b>world</b>&nbsp;!</p>]]></description>
+  </item>
+  <item>
+    <title>Second post</title>
+    <link>https://www.example.com/posts/second</link>
+    <pubDate>Thu, 2 May 2024 10:00:00 GMT</pubDate>
+    <content:encoded><![CDATA[<div>Full content</div>]]></content:encoded>
+  </item>
+</channel>
+</rss>`
+
+const testAtom = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title type="html">Example &amp;lt;Atom&amp;gt;</title>
+  <subtitle>Subtitle</subtitle>
+  <link rel="self" href="/atom.xml"/>
+  <link href="https://www.example.com/"/>
+  <entry>
+    <id>urn:uuid:1</id>
+    <title>Atom entry</title>
+    <link rel="alternate" href="/entries/1"/>
+    <updated>2024-05-03T10:00:00Z</updated>
+    <author><name>John Doe</name></author>
+    <content type="xhtml"><div xmlns="http://www.w3.org/1999/xhtml"><p>XHTML content</p></div></content>
+  </entry>
+</feed>`
+
+const testJSONFeed = `{
+  "version": "https://jsonfeed.org/version/1.1",
+  "title": "JSON Blog",
+  "home_page_url": "https://www.example.com/",
+  "items": [
+    {"id": 42, "url": "/json/1", "title": "JSON item", "content_html": "<p>HTML content</p>",
+     "date_published": "2024-05-04T10:00:00+02:00", "authors": [{"name": "Ann"}]}
+  ]
+}`
+
+func TestParseFeed(t *testing.T) {
+    feed, err := ParseFeed([]byte(testRSS), "https://www.example.com/feed.xml")
+    if err != nil {
+        t.Fatalf("ParseFeed() error = %v", err)
+    }
+    if feed.Type != FeedTypeRSS || feed.Title != "Example Blog" || feed.Description != "News & updates" || feed.Link != "https://www.example.com/" {
+        t.Errorf("unexpected feed %+v", feed)
+    }
+    wantRSS := []FeedItem{
+        {ID: "post-1", Title: "First post", Link: "https://www.example.com/posts/first", Published: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), Author: "Jane Doe", Summary: "Hello world !"},
+        {ID: "https://www.example.com/posts/second", Title: "Second post", Link: "https://www.example.com/posts/second", Author: "", Summary: "Full content"},
+    }
+    if len(feed.Items) != 2 {
+        t.Fatalf("len(Items) = %d", len(feed.Items))
+    }
+    if feed.Items[1].Published.IsZero() {
+        t.Errorf("failed to parse RSS date with a GMT zone")
+    }
+    feed.Items[0].Published = feed.Items[0].Published.UTC()
+    feed.Items[1].Published = time.Time{}
+    if !reflect.DeepEqual(feed.Items, wantRSS) {
+        t.Errorf("Items = %+v, want %+v", feed.Items, wantRSS)
+    }
+
+    feed, err = ParseFeed([]byte(testAtom), "https://www.example.com/atom.xml")
+    if err != nil {
+        t.Fatalf("ParseFeed() error = %v", err)
+    }
+    updated := time.Date(2024, 5, 3, 10, 0, 0, 0, time.UTC)
+    wantAtom := []FeedItem{{ID: "urn:uuid:1", Title: "Atom entry", Link: "https://www.example.com/entries/1", Published: updated, Updated: updated, Author: "John Doe", Summary: "XHTML content"}}
+    if feed.Type != FeedTypeAtom || feed.Title != "Example <Atom>" || feed.Description != "Subtitle" || feed.Link != "https://www.example.com/" {
+        t.Errorf("unexpected feed %+v", feed)
+    }
+    if !reflect.DeepEqual(feed.Items, wantAtom) {
+        t.Errorf("Items = %+v, want %+v", feed.Items, wantAtom)
+    }
+
+    feed, err = ParseFeed([]byte(testJSONFeed), "https://www.example.com/feed.json")
+    if err != nil {
+        t.Fatalf("ParseFeed() error = %v", err)
+    }
+    if feed.Type != FeedTypeJSON || feed.Title != "JSON Blog" || len(feed.Items) != 1 {
+        t.Fatalf("unexpected feed %+v", feed)
+    }
+    item := feed.Items[0]
+    if item.ID != "42" || item.Link != "https://www.example.com/json/1" || item.Author != "Ann" || item.Summary != "HTML content" || !item.Published.Equal(time.Date(2024, 5, 4, 8, 0, 0, 0, time.UTC)) {
+        t.Errorf("unexpected item %+v", item)
+    }
+
+    for _, invalid := range []string{"<html><body></body></html>", `{"version": "1", "items": []}`, "{"} {
+        if _, err := ParseFeed([]byte(invalid), "https://www.example.com/feed"); err == nil {
+            t.Errorf("expected an error for %q", invalid)
+        }
+    }
+}
+
+func TestParseFeed_ZoneAbbreviations(t *testing.T) {
+    tests := []struct {
+        pubDate  string
+        expected time.Time
+    }{
+        {"Mon, 02 Jan 2006 15:04:05 PST", time.Date(2006, 1, 2, 23, 4, 5, 0, time.UTC)},
+        {"Mon, 02 Jan 2006 15:04:05 EDT", time.Date(2006, 1, 2, 19, 4, 5, 0, time.UTC)},
+        {"2 Jan 2006 15:04:05 CST", time.Date(2006, 1, 2, 21, 4, 5, 0, time.UTC)},
+        {"Mon, 02 Jan 2006 15:04:05 GMT", time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)},
+    }
+    for _, tt := range tests {
+        rss := `<rss version="2.0"><channel><title>Zones</title><item><title>Item</title><pubDate>` + tt.pubDate + `</pubDate></item></channel></rss>`
+        feed, err := ParseFeed([]byte(rss), "https://www.example.com/feed.xml")
+        if err != nil {
+            t.Fatalf("ParseFeed() error = %v", err)
+        }
+        if published := feed.Items[0].Published; !published.Equal(tt.expected) {
+            t.Errorf("pubDate %q: Published = %v, want %v", tt.pubDate, published.UTC(), tt.expected)
+        }
+    }
+}
+
+func TestParseFeed_Charsets(t *testing.T) {
+    tests := []struct {
+        name  string
+        data  []byte
+        title string
+    }{
+        {
+            name:  "ISO-8859-1",
+            data:  append([]byte(`<?xml version="1.0" encoding="ISO-8859-1"?><rss version="2.0"><channel><title>Caf`), append([]byte{0xe9, ' ', 'M', 0xfc, 'n', 'c', 'h', 'e', 'n'}, []byte(`</title></channel></rss>`)...)...),
+            title: "Café München",
+        },
+        {
+            name:  "windows-1251",
+            data:  append([]byte(`<?xml version="1.0" encoding="windows-1251"?><rss version="2.0"><channel><title>`), append([]byte{0xcd, 0xee, 0xe2, 0xee, 0xf1, 0xf2, 0xe8}, []byte(`</title></channel></rss>`)...)...),
+            title: "Новости",
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            feed, err := ParseFeed(tt.data, "https://www.example.com/feed")
+            if err != nil {
+                t.Fatalf("ParseFeed() error = %v", err)
+            }
+            if feed.Title != tt.title {
+                t.Errorf("Title = %q, want %q", feed.Title, tt.title)
+            }
+        })
+    }
+}
+
+// feedServer serves an HTML page with the given head and the test feeds
+func feedServer(t *testing.T, htmlHead string) *httptest.Server {
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        switch r.URL.Path {
+        case "/":
+            w.Header().Set("Content-Type", "text/html")
+            w.Write([]byte("<html><head>" + htmlHead + "</head><body></body></html>"))
+        case "/feed.xml":
+            w.Write([]byte(testRSS))
+        case "/atom.xml":
+            w.Write([]byte(testAtom))
+        case "/feed.json":
+            w.Write([]byte(testJSONFeed))
+        case "/wp-json/wp/v2/pages/1":
+            w.Header().Set("Content-Type", "application/json")
+            w.Write([]byte(`{"id": 1, "title": {"rendered": "Page"}}`))
+        default:
+            w.WriteHeader(http.StatusNotFound)
+        }
+    }))
+    t.Cleanup(server.Close)
+    return server
+}
+
+func TestGetFeeds(t *testing.T) {
+    head := `<link rel="alternate" type="application/rss+xml" title="RSS" href="/feed.xml">
+        <link rel="alternate" type="application/atom+xml" href="atom.xml">
+        <link rel="alternate" type="application/feed+json" title="JSON" href="/feed.json">
+        <link rel="alternate" type="application/rss+xml" href="/missing.xml">
+        <link rel="alternate" hreflang="de" href="/de/">
+        <link rel="stylesheet" type="application/rss+xml" href="/not-a-feed.xml">`
+
+    server := feedServer(t, head)
+    feeds, err := NewFetcher(nil).GetFeeds(server.URL, GetFeedsProps{})
+    if err != nil {
+        t.Fatalf("GetFeeds() error = %v", err)
+    }
+    want := []Feed{
+        {URL: server.URL + "/feed.xml", Type: FeedTypeRSS, Title: "RSS"},
+        {URL: server.URL + "/atom.xml", Type: FeedTypeAtom},
+        {URL: server.URL + "/feed.json", Type: FeedTypeJSON, Title: "JSON"},
+        {URL: server.URL + "/missing.xml", Type: FeedTypeRSS},
+    }
+    if !reflect.DeepEqual(feeds, want) {
+        t.Errorf("GetFeeds() = %+v, want %+v", feeds, want)
+    }
+
+    feeds, err = NewFetcher(nil).GetFeeds(server.URL, GetFeedsProps{Parse: true})
+    if err != nil {
+        t.Fatalf("GetFeeds() error = %v", err)
+    }
+    if feeds[0].Title != "Example Blog" || len(feeds[0].Items) != 2 || len(feeds[1].Items) != 1 || len(feeds[2].Items) != 1 {
+        t.Errorf("feeds not parsed: %+v", feeds)
+    }
+    if feeds[3].Err == nil || feeds[3].URL != server.URL+"/missing.xml" {
+        t.Errorf("expected an error for the missing feed, got %+v", feeds[3])
+    }
+
+    // probing common paths
+    probed := feedServer(t, "<title>No feeds</title>")
+    feeds, err = NewFetcher(nil).GetFeeds(probed.URL, GetFeedsProps{})
+    if err != nil {
+        t.Fatalf("GetFeeds() error = %v", err)
+    }
+    if len(feeds) != 1 || feeds[0].URL != probed.URL+"/feed.xml" || feeds[0].Title != "Example Blog" || feeds[0].Items != nil {
+        t.Errorf("unexpected probed feeds %+v", feeds)
+    }
+
+    // plain JSON links are only feeds if they serve a JSON Feed, REST API links are not
+    rest := feedServer(t, `<link rel="alternate" type="application/json" href="/wp-json/wp/v2/pages/1">`)
+    feeds, err = NewFetcher(nil).GetFeeds(rest.URL, GetFeedsProps{})
+    if err != nil || len(feeds) != 1 || feeds[0].URL != rest.URL+"/feed.xml" {
+        t.Errorf("expected the probed feed instead of the REST API link, got %+v (%v)", feeds, err)
+    }
+    plainJSON := feedServer(t, `<link rel="alternate" type="application/json" title="JSON" href="/feed.json">`)
+    feeds, err = NewFetcher(nil).GetFeeds(plainJSON.URL, GetFeedsProps{})
+    want = []Feed{{URL: plainJSON.URL + "/feed.json", Type: FeedTypeJSON, Title: "JSON"}}
+    if err != nil || !reflect.DeepEqual(feeds, want) {
+        t.Errorf("GetFeeds() = %+v (%v), want %+v", feeds, err, want)
+    }
+
+    _, err = NewFetcher(nil).GetFeeds(probed.URL, GetFeedsProps{NoProbe: true})
+    if err == nil || !strings.Contains(err.Error(), "GetFeeds failed to find any feeds") {
+        t.Errorf("expected an error without feeds, got %v", err)
+    }
+}
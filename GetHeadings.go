@@ -0,0 +1,151 @@
+package katsuragi
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// headingLevels maps the heading elements to their level
+var headingLevels = map[string]int{"h1": 1, "h2": 2, "h3": 3, "h4": 4, "h5": 5, "h6": 6}
+
+// GetHeadings fetches the <h1>-<h6> outline of the given URL as a tree: every heading holds the headings
+// of lower levels following it. Hidden headings are left out. Outline problems are flagged on the headings
+// and listed once in Outline.Issues: missing or multiple <h1>, skipped levels and empty headings.
+func (f *Fetcher) GetHeadings(url string) (*Outline, error) {
+    document, err := retrieveDocument(url, f)
+    if err != nil {
+        return nil, err
+    }
+    outline := extractHeadings(document.root, url)
+    if outline.Count == 0 {
+        return nil, fmt.Errorf("GetHeadings failed to find headings in HTML")
+    }
+    return outline, nil
+}
+
+// Headings extracts the heading outline of the document, see GetHeadings
+func (d *Document) Headings() (*Outline, error) {
+    outline := extractHeadings(d.root, d.url)
+    if outline.Count == 0 {
+        return nil, fmt.Errorf("Headings failed to find headings in HTML")
+    }
+    return outline, nil
+}
+
+// extractHeadings builds the heading tree of the document and flags its issues
+func extractHeadings(doc *html.Node, url string) *Outline {
+    outline := &Outline{}
+    var stack []*Heading
+    h1Count := 0
+    // the first heading may be a <h2> without skipping a level, a missing <h1> is flagged on its own
+    previousLevel := 1
+
+    var traverse func(*html.Node)
+    traverse = func(n *html.Node) {
+        if n.Type == html.ElementNode {
+            if isHiddenElement(n) {
+                return
+            }
+            if level, found := headingLevels[n.Data]; found {
+                heading := &Heading{
+                    Level:    level,
+                    Text:     headingText(n),
+                    ID:       headingID(n),
+                    Position: outline.Count,
+                }
+                if heading.ID != "" {
+                    heading.URL = ensureAbsoluteURL("#"+heading.ID, url)
+                }
+                if level == 1 {
+                    h1Count++
+                    if h1Count > 1 {
+                        heading.Issues = append(heading.Issues, HeadingIssueMultipleH1)
+                    }
+                }
+                if level > previousLevel+1 {
+                    heading.Issues = append(heading.Issues, HeadingIssueSkippedLevel)
+                }
+                if heading.Text == "" {
+                    heading.Issues = append(heading.Issues, HeadingIssueEmpty)
+                }
+                for _, issue := range heading.Issues {
+                    if !contains(outline.Issues, issue) {
+                        outline.Issues = append(outline.Issues, issue)
+                    }
+                }
+                previousLevel = level
+                outline.Count++
+
+                for len(stack) > 0 && stack[len(stack)-1].Level >= level {
+                    stack = stack[:len(stack)-1]
+                }
+                if len(stack) == 0 {
+                    outline.Headings = append(outline.Headings, heading)
+                } else {
+                    parent := stack[len(stack)-1]
+                    parent.Children = append(parent.Children, heading)
+                }
+                stack = append(stack, heading)
+                // headings are not nested
+                return
+            }
+        }
+        for c := n.FirstChild; c != nil; c = c.NextSibling {
+            traverse(c)
+        }
+    }
+    traverse(doc)
+
+    if outline.Count > 0 && h1Count == 0 {
+        outline.Issues = append([]HeadingIssue{HeadingIssueMissingH1}, outline.Issues...)
+    }
+    return outline
+}
+
+// headingText returns the visible text of the heading on a single line, or the alt text of its images
+func headingText(n *html.Node) string {
+    text := strings.Join(strings.Fields(extractVisibleText(n)), " ")
+    if text != "" {
+        return text
+    }
+    var alts []string
+    var traverse func(*html.Node)
+    traverse = func(n *html.Node) {
+        if n.Type == html.ElementNode && n.Data == "img" {
+            if alt := strings.TrimSpace(extractAttributes(n.Attr)["alt"]); alt != "" {
+                alts = append(alts, alt)
+            }
+        }
+        for c := n.FirstChild; c != nil; c = c.NextSibling {
+            traverse(c)
+        }
+    }
+    traverse(n)
+    return strings.Join(alts, " ")
+}
+
+// headingID returns the id of the heading, or the id or name of an anchor inside it (<h2><a id="intro"></a>Intro</h2>)
+func headingID(n *html.Node) string {
+    if id := strings.TrimSpace(extractAttributes(n.Attr)["id"]); id != "" {
+        return id
+    }
+    var id string
+    var traverse func(*html.Node)
+    traverse = func(n *html.Node) {
+        for c := n.FirstChild; c != nil && id == ""; c = c.NextSibling {
+            if c.Type == html.ElementNode {
+                attrMap := extractAttributes(c.Attr)
+                if id = strings.TrimSpace(attrMap["id"]); id == "" && c.Data == "a" {
+                    id = strings.TrimSpace(attrMap["name"])
+                }
+                if id == "" {
+                    traverse(c)
+                }
+            }
+        }
+    }
+    traverse(n)
+    return id
+}
@@ -0,0 +1,73 @@
+package katsuragi
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetHeadings(t *testing.T) {
+    server := MockServer(t, `<html><head><title>Guide</title></head><body>
+        <h1 id="guide">The <em>complete</em>
+            guide</h1>
+        <h2><a name="setup"></a>Setup</h2>
+        <h3 id="install">Install</h3>
+        <h3>Configure</h3>
+        <h2 id="usage">Usage</h2>
+        <h4>Advanced</h4>
+        <h2 hidden>Hidden</h2>
+        <h1><img src="/logo.png" alt="Logo"></h1>
+        <h2></h2>
+        </body></html>`)
+    defer server.Close()
+
+    outline, err := NewFetcher(nil).GetHeadings(server.URL)
+    if err != nil {
+        t.Fatalf("GetHeadings() error = %v", err)
+    }
+    if outline.Count != 8 || len(outline.Headings) != 2 {
+        t.Fatalf("Expected 8 headings and 2 roots, got %v and %v", outline.Count, len(outline.Headings))
+    }
+    expectedIssues := []HeadingIssue{HeadingIssueSkippedLevel, HeadingIssueMultipleH1, HeadingIssueEmpty}
+    if !reflect.DeepEqual(outline.Issues, expectedIssues) {
+        t.Errorf("Expected issues %v, got %v", expectedIssues, outline.Issues)
+    }
+
+    guide := outline.Headings[0]
+    if guide.Level != 1 || guide.Text != "The complete guide" || guide.ID != "guide" || guide.URL != server.URL+"#guide" || guide.Position != 0 || len(guide.Issues) != 0 {
+        t.Errorf("Unexpected heading %+v", guide)
+    }
+    if len(guide.Children) != 2 {
+        t.Fatalf("Expected 2 children, got %v", len(guide.Children))
+    }
+    setup, usage := guide.Children[0], guide.Children[1]
+    if setup.Text != "Setup" || setup.ID != "setup" || len(setup.Children) != 2 || setup.Children[0].ID != "install" || setup.Children[1].Text != "Configure" || setup.Children[1].URL != "" {
+        t.Errorf("Unexpected heading %+v", setup)
+    }
+    if usage.Position != 4 || len(usage.Children) != 1 || !reflect.DeepEqual(usage.Children[0].Issues, []HeadingIssue{HeadingIssueSkippedLevel}) {
+        t.Errorf("Unexpected heading %+v", usage)
+    }
+
+    logo := outline.Headings[1]
+    if logo.Text != "Logo" || !reflect.DeepEqual(logo.Issues, []HeadingIssue{HeadingIssueMultipleH1}) || len(logo.Children) != 1 {
+        t.Errorf("Unexpected heading %+v", logo)
+    }
+    if empty := logo.Children[0]; empty.Position != 7 || !reflect.DeepEqual(empty.Issues, []HeadingIssue{HeadingIssueEmpty}) {
+        t.Errorf("Unexpected heading %+v", empty)
+    }
+}
+
+func TestDocumentHeadings(t *testing.T) {
+    doc, _ := ParseString(`<html><body><h2>Intro</h2><h3>Details</h3><h2>End</h2></body></html>`, "https://www.example.com")
+    outline, err := doc.Headings()
+    if err != nil {
+        t.Fatalf("Headings() error = %v", err)
+    }
+    if !reflect.DeepEqual(outline.Issues, []HeadingIssue{HeadingIssueMissingH1}) || len(outline.Headings) != 2 || len(outline.Headings[0].Children) != 1 {
+        t.Errorf("Unexpected outline %+v", outline)
+    }
+
+    empty, _ := ParseString(`<html><body><p>No headings</p></body></html>`, "https://www.example.com")
+    if _, err := empty.Headings(); err == nil || err.Error() != "Headings failed to find headings in HTML" {
+        t.Errorf("Expected error, got %v", err)
+    }
+}
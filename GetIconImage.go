@@ -0,0 +1,214 @@
+package katsuragi
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	Url "net/url"
+	"strconv"
+	"strings"
+)
+
+// GetIconImage downloads the icon at the given URL (data: URLs are decoded instead), verifies that the content
+// is an image and reports its actual format and pixel dimensions
+func (f *Fetcher) GetIconImage(iconURL string) (*IconImage, error) {
+    var data []byte
+    if strings.HasPrefix(iconURL, "data:") {
+        decoded, _, err := decodeDataURL(iconURL)
+        if err != nil {
+            return nil, err
+        }
+        data = decoded
+    } else {
+        fetched, _, err := fetchResource(iconURL, f)
+        if err != nil {
+            return nil, err
+        }
+        data = fetched
+    }
+    iconImage, err := DecodeIconImage(data)
+    if err != nil {
+        return nil, err
+    }
+    iconImage.URL = iconURL
+    return iconImage, nil
+}
+
+// DecodeIconImage detects the format and the pixel dimensions of an image.
+// Supported formats: PNG, JPEG, GIF, WebP, ICO (all images of the directory are reported) and SVG (width/height or viewBox).
+func DecodeIconImage(data []byte) (*IconImage, error) {
+    iconImage := &IconImage{Data: data}
+
+    if config, format, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+        iconImage.Format = format
+        iconImage.Width, iconImage.Height = config.Width, config.Height
+    } else if sizes, ok := decodeIcoDirectory(data); ok {
+        iconImage.Format = "ico"
+        iconImage.Images = sizes
+        for _, size := range sizes {
+            if size.Width*size.Height > iconImage.Width*iconImage.Height {
+                iconImage.Width, iconImage.Height = size.Width, size.Height
+            }
+        }
+    } else if width, height, ok := decodeWebpSize(data); ok {
+        iconImage.Format = "webp"
+        iconImage.Width, iconImage.Height = width, height
+    } else if width, height, ok := decodeSvgSize(data); ok {
+        iconImage.Format = "svg"
+        iconImage.Width, iconImage.Height = width, height
+    } else {
+        return nil, fmt.Errorf("DecodeIconImage failed to recognize image format")
+    }
+
+    iconImage.MIMEType = imageMIMETypes[iconImage.Format]
+    return iconImage, nil
+}
+
+// Square reports whether the image has a 1:1 aspect ratio
+func (i *IconImage) Square() bool {
+    return i.Width > 0 && i.Width == i.Height
+}
+
+var imageMIMETypes = map[string]string{
+    "png":  "image/png",
+    "jpeg": "image/jpeg",
+    "gif":  "image/gif",
+    "webp": "image/webp",
+    "ico":  "image/x-icon",
+    "svg":  "image/svg+xml",
+}
+
+// decodeDataURL decodes the payload of a data: URL and returns it with its MIME type
+func decodeDataURL(dataURL string) ([]byte, string, error) {
+    header, payload, found := strings.Cut(strings.TrimPrefix(dataURL, "data:"), ",")
+    if !found {
+        return nil, "", fmt.Errorf("decodeDataURL failed to decode data URL: missing comma")
+    }
+    mimeType, params, _ := strings.Cut(header, ";")
+    if strings.HasSuffix(params, "base64") {
+        // Some data URLs are not padded or contain whitespace
+        payload = strings.Join(strings.Fields(payload), "")
+        data, err := base64.StdEncoding.DecodeString(payload)
+        if err != nil {
+            data, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(payload, "="))
+        }
+        if err != nil {
+            return nil, "", fmt.Errorf("decodeDataURL failed to decode data URL: %v", err)
+        }
+        return data, mimeType, nil
+    }
+    data, err := Url.PathUnescape(payload)
+    if err != nil {
+        return nil, "", fmt.Errorf("decodeDataURL failed to decode data URL: %v", err)
+    }
+    return []byte(data), mimeType, nil
+}
+
+// decodeIcoDirectory reads the image directory of an ICO file and returns the size of every image
+func decodeIcoDirectory(data []byte) ([]IconSize, bool) {
+    // ICONDIR: reserved (0), type (1 = icon), number of images
+    if len(data) < 6 || binary.LittleEndian.Uint16(data[0:]) != 0 || binary.LittleEndian.Uint16(data[2:]) != 1 {
+        return nil, false
+    }
+    count := int(binary.LittleEndian.Uint16(data[4:]))
+    if count == 0 || len(data) < 6+count*16 {
+        return nil, false
+    }
+    sizes := make([]IconSize, 0, count)
+    for i := 0; i < count; i++ {
+        // ICONDIRENTRY: width and height of 0 mean 256 pixels
+        entry := data[6+i*16:]
+        width, height := int(entry[0]), int(entry[1])
+        if width == 0 {
+            width = 256
+        }
+        if height == 0 {
+            height = 256
+        }
+        sizes = append(sizes, IconSize{Width: width, Height: height})
+    }
+    return sizes, true
+}
+
+// decodeWebpSize reads the canvas size from the header of a WebP file (lossy, lossless or extended)
+func decodeWebpSize(data []byte) (int, int, bool) {
+    if len(data) < 30 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+        return 0, 0, false
+    }
+    switch string(data[12:16]) {
+    case "VP8 ":
+        // frame tag (3 bytes) + start code (3 bytes), then 14-bit width and height
+        width := int(binary.LittleEndian.Uint16(data[26:]) & 0x3fff)
+        height := int(binary.LittleEndian.Uint16(data[28:]) & 0x3fff)
+        return width, height, true
+    case "VP8L":
+        // signature byte, then 14-bit width-1 and height-1
+        bits := binary.LittleEndian.Uint32(data[21:])
+        return int(bits&0x3fff) + 1, int((bits>>14)&0x3fff) + 1, true
+    case "VP8X":
+        // 24-bit canvas width-1 and height-1
+        width := int(data[24]) | int(data[25])<<8 | int(data[26])<<16
+        height := int(data[27]) | int(data[28])<<8 | int(data[29])<<16
+        return width + 1, height + 1, true
+    }
+    return 0, 0, false
+}
+
+// decodeSvgSize reads the size of an SVG image from the width/height attributes of the root element,
+// falling back to its viewBox
+func decodeSvgSize(data []byte) (int, int, bool) {
+    decoder := xml.NewDecoder(bytes.NewReader(data))
+    decoder.Strict = false
+    for {
+        token, err := decoder.Token()
+        if err != nil {
+            return 0, 0, false
+        }
+        start, ok := token.(xml.StartElement)
+        if !ok {
+            continue
+        }
+        // the root element must be <svg>
+        if start.Name.Local != "svg" {
+            return 0, 0, false
+        }
+        var width, height float64
+        var viewBox string
+        for _, attr := range start.Attr {
+            switch attr.Name.Local {
+            case "width":
+                width = parseSvgLength(attr.Value)
+            case "height":
+                height = parseSvgLength(attr.Value)
+            case "viewBox":
+                viewBox = attr.Value
+            }
+        }
+        if width == 0 || height == 0 {
+            fields := strings.FieldsFunc(viewBox, func(r rune) bool { return r == ' ' || r == ',' })
+            if len(fields) == 4 {
+                width, _ = strconv.ParseFloat(fields[2], 64)
+                height, _ = strconv.ParseFloat(fields[3], 64)
+            }
+        }
+        // an SVG without any size is still a valid (scalable) image
+        return int(math.Round(width)), int(math.Round(height)), true
+    }
+}
+
+// parseSvgLength parses absolute SVG lengths ("32", "32px"), relative lengths ("100%", "2em") return 0
+func parseSvgLength(value string) float64 {
+    value = strings.TrimSuffix(strings.TrimSpace(value), "px")
+    length, err := strconv.ParseFloat(value, 64)
+    if err != nil || length < 0 {
+        return 0
+    }
+    return length
+}
@@ -0,0 +1,135 @@
+package katsuragi
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// testPng encodes an empty PNG image of the given size
+func testPng(width, height int) []byte {
+    var buf bytes.Buffer
+    png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height)))
+    return buf.Bytes()
+}
+
+// testIco builds an ICO directory with one (empty) image per size
+func testIco(sizes ...IconSize) []byte {
+    data := make([]byte, 6+len(sizes)*16)
+    binary.LittleEndian.PutUint16(data[2:], 1)
+    binary.LittleEndian.PutUint16(data[4:], uint16(len(sizes)))
+    for i, size := range sizes {
+        data[6+i*16] = byte(size.Width % 256)
+        data[6+i*16+1] = byte(size.Height % 256)
+    }
+    return data
+}
+
+func TestDecodeIconImage(t *testing.T) {
+    webpLossless := []byte("RIFF\x00\x00\x00\x00WEBPVP8L\x00\x00\x00\x00\x2f")
+    // 48x48: width-1 and height-1 packed in 14 bits each
+    webpLossless = binary.LittleEndian.AppendUint32(webpLossless, 47|47<<14)
+    webpLossless = append(webpLossless, make([]byte, 8)...)
+
+    tests := []struct {
+        name           string
+        data           []byte
+        expectedFormat string
+        expectedWidth  int
+        expectedHeight int
+        expectedImages []IconSize
+        expectedErr    string
+    }{
+        {name: "PNG", data: testPng(32, 16), expectedFormat: "png", expectedWidth: 32, expectedHeight: 16},
+        {name: "ICO", data: testIco(IconSize{16, 16}, IconSize{256, 256}, IconSize{32, 32}), expectedFormat: "ico", expectedWidth: 256, expectedHeight: 256, expectedImages: []IconSize{{16, 16}, {256, 256}, {32, 32}}},
+        {name: "WebP", data: webpLossless, expectedFormat: "webp", expectedWidth: 48, expectedHeight: 48},
+        {name: "SVG width/height", data: []byte(`<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg" width="24px" height="24"></svg>`), expectedFormat: "svg", expectedWidth: 24, expectedHeight: 24},
+        {name: "SVG viewBox", data: []byte(`<svg xmlns="http://www.w3.org/2000/svg" width="100%" viewBox="0 0 64 48"></svg>`), expectedFormat: "svg", expectedWidth: 64, expectedHeight: 48},
+        {name: "HTML", data: []byte(`<html><body>Not Found</body></html>`), expectedErr: "DecodeIconImage failed to recognize image format"},
+        {name: "Empty", data: []byte{}, expectedErr: "DecodeIconImage failed to recognize image format"},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            iconImage, err := DecodeIconImage(tt.data)
+            if tt.expectedErr != "" {
+                if err == nil || err.Error() != tt.expectedErr {
+                    t.Fatalf("Expected error %q, got %v", tt.expectedErr, err)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("Expected no error, got: %v", err)
+            }
+            if iconImage.Format != tt.expectedFormat || iconImage.Width != tt.expectedWidth || iconImage.Height != tt.expectedHeight {
+                t.Errorf("Expected %s %dx%d, got %s %dx%d", tt.expectedFormat, tt.expectedWidth, tt.expectedHeight, iconImage.Format, iconImage.Width, iconImage.Height)
+            }
+            if !reflect.DeepEqual(iconImage.Images, tt.expectedImages) {
+                t.Errorf("Expected images %v, got %v", tt.expectedImages, iconImage.Images)
+            }
+        })
+    }
+}
+
+func TestGetIconImage(t *testing.T) {
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path == "/favicon.png" {
+            w.Write(testPng(64, 64))
+            return
+        }
+        w.WriteHeader(http.StatusNotFound)
+    }))
+    defer server.Close()
+    f := NewFetcher(nil)
+
+    iconImage, err := f.GetIconImage(server.URL + "/favicon.png")
+    if err != nil {
+        t.Fatalf("Expected no error, got: %v", err)
+    }
+    if iconImage.URL != server.URL+"/favicon.png" || iconImage.MIMEType != "image/png" || !iconImage.Square() {
+        t.Errorf("Unexpected icon image: %s %s %dx%d", iconImage.URL, iconImage.MIMEType, iconImage.Width, iconImage.Height)
+    }
+
+    dataURL := "data:image/png;base64," + base64.StdEncoding.EncodeToString(testPng(16, 8))
+    iconImage, err = f.GetIconImage(dataURL)
+    if err != nil {
+        t.Fatalf("Expected no error, got: %v", err)
+    }
+    if iconImage.Width != 16 || iconImage.Height != 8 || iconImage.Square() {
+        t.Errorf("Expected non-square 16x8 image, got %dx%d", iconImage.Width, iconImage.Height)
+    }
+
+    if _, err := f.GetIconImage(server.URL + "/missing.png"); err == nil {
+        t.Errorf("Expected error for missing icon, got none")
+    }
+}
+
+func TestDecodeDataURL(t *testing.T) {
+    tests := []struct {
+        dataURL      string
+        expectedData string
+        expectedType string
+        expectErr    bool
+    }{
+        {"data:image/svg+xml,%3Csvg%3E%3C/svg%3E", "<svg></svg>", "image/svg+xml", false},
+        {"data:image/png;base64,aGVsbG8=", "hello", "image/png", false},
+        {"data:image/png;base64,aGVsbG8", "hello", "image/png", false},
+        {"data:image/png;base64,!!!", "", "", true},
+        {"data:image/png", "", "", true},
+    }
+    for _, tt := range tests {
+        data, mimeType, err := decodeDataURL(tt.dataURL)
+        if (err != nil) != tt.expectErr {
+            t.Fatalf("decodeDataURL(%q): expected error: %v, got: %v", tt.dataURL, tt.expectErr, err)
+        }
+        if string(data) != tt.expectedData || mimeType != tt.expectedType {
+            t.Errorf("decodeDataURL(%q) = %q, %q; want %q, %q", tt.dataURL, data, mimeType, tt.expectedData, tt.expectedType)
+        }
+    }
+}
@@ -0,0 +1,180 @@
+package katsuragi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// GetIcons fetches the icons of the given URL along with their declared attributes (rel, sizes, type, media, color)
+// and the source they were discovered from.
+func (f *Fetcher) GetIcons(url string) ([]Icon, error) {
+    htmlDoc, err := retrieveHTML(url, f)
+    if err != nil {
+        return nil, err
+    }
+    icons := traverseAndExtractIcons(htmlDoc, url)
+    // PWAs often declare their high-resolution icons only in the manifest, Windows tiles in browserconfig.xml
+    for _, icon := range append(getManifestIcons(htmlDoc, url, f), getBrowserConfigIcons(htmlDoc, url, f)...) {
+        if !containsIcon(icons, icon.URL) {
+            icons = append(icons, icon)
+        }
+    }
+    if len(icons) == 0 {
+        // Same fallback as GetFavicons: browsers request /favicon.ico when no icon is declared
+        var rootFavicon []string
+        if getRootFaviconIco(&rootFavicon, url) == nil && len(rootFavicon) > 0 {
+            icons = append(icons, Icon{
+                URL:    rootFavicon[0],
+                Type:   "image/x-icon",
+                Source: IconSourceRoot,
+            })
+        }
+    }
+    if len(icons) == 0 {
+        return nil, fmt.Errorf("GetIcons failed to find any icons")
+    }
+    return icons, nil
+}
+
+// Icons extracts the icons declared in the document, see GetIcons.
+// The root /favicon.ico is not probed since no network requests are made.
+func (d *Document) Icons() ([]Icon, error) {
+    icons := traverseAndExtractIcons(d.root, d.url)
+    if len(icons) == 0 {
+        return nil, fmt.Errorf("Icons failed to find any icons")
+    }
+    return icons, nil
+}
+
+// traverseAndExtractIcons traverses the HTML node tree and extracts icons with their attributes.
+// It follows the same rules as traverseAndExtractFavicons.
+func traverseAndExtractIcons(n *html.Node, url string) []Icon {
+    var icons []Icon
+    seen := make(map[string]bool)
+    tileColor := ""
+
+    add := func(icon Icon) {
+        icon.URL = ensureAbsoluteURL(icon.URL, url)
+        if !seen[icon.URL] {
+            seen[icon.URL] = true
+            icons = append(icons, icon)
+        }
+    }
+
+    var traverse func(*html.Node)
+    traverse = func(n *html.Node) {
+        if n.Type == html.ElementNode && (n.Data == "link" || n.Data == "meta") && n.Parent != nil && n.Parent.Data == "head" {
+            attrMap := extractAttributes(n.Attr)
+            if n.Data == "link" {
+                if rel, isIcon := isIconRel(attrMap["rel"]); isIcon {
+                    if href, found := attrMap["href"]; found {
+                        sizes, anySize := parseIconSizes(attrMap["sizes"])
+                        add(Icon{
+                            URL:     href,
+                            Rel:     rel,
+                            Sizes:   sizes,
+                            AnySize: anySize,
+                            Type:    attrMap["type"],
+                            Media:   attrMap["media"],
+                            Color:   attrMap["color"],
+                            Source:  IconSourceLink,
+                        })
+                    }
+                }
+            // Windows tile image and color
+            } else if name := strings.ToLower(attrMap["name"]); name == "msapplication-tileimage" {
+                if content := attrMap["content"]; content != "" {
+                    add(Icon{
+                        URL:    content,
+                        Sizes:  []IconSize{{Width: 144, Height: 144}}, // size expected by Windows 8
+                        Source: IconSourceMSApplication,
+                    })
+                }
+            } else if name == "msapplication-tilecolor" {
+                tileColor = attrMap["content"]
+            // og:image + aspect ratio check
+            } else if property, found := attrMap["property"]; found && property == "og:image" {
+                if content, found := attrMap["content"]; found && checkOgImageAspectRatio(n) {
+                    width, height, mimeType := extractOgImageDetails(n)
+                    add(Icon{
+                        URL:    content,
+                        Sizes:  []IconSize{{Width: width, Height: height}},
+                        Type:   mimeType,
+                        Source: IconSourceOpenGraph,
+                    })
+                }
+            }
+        }
+        for c := n.FirstChild; c != nil; c = c.NextSibling {
+            traverse(c)
+        }
+    }
+    traverse(n)
+
+    for i := range icons {
+        if icons[i].Source == IconSourceMSApplication {
+            icons[i].Color = tileColor
+        }
+    }
+    return icons
+}
+
+// containsIcon checks if an icon with the given URL is in a slice
+func containsIcon(icons []Icon, url string) bool {
+    for _, icon := range icons {
+        if icon.URL == url {
+            return true
+        }
+    }
+    return false
+}
+
+// parseIconSizes parses the value of a `sizes` attribute (e.g. "16x16 32x32" or "any").
+// Invalid tokens are skipped.
+func parseIconSizes(value string) ([]IconSize, bool) {
+    var sizes []IconSize
+    anySize := false
+    for _, token := range strings.Fields(strings.ToLower(value)) {
+        if token == "any" {
+            anySize = true
+            continue
+        }
+        width, height, found := strings.Cut(token, "x")
+        if !found {
+            continue
+        }
+        w, errW := strconv.Atoi(width)
+        h, errH := strconv.Atoi(height)
+        if errW != nil || errH != nil || w <= 0 || h <= 0 {
+            continue
+        }
+        sizes = append(sizes, IconSize{Width: w, Height: h})
+    }
+    return sizes, anySize
+}
+
+// extractOgImageDetails reads og:image:width, og:image:height and og:image:type from the sibling nodes
+// following an og:image meta tag, stopping at the next og:image.
+func extractOgImageDetails(n *html.Node) (int, int, string) {
+    width, height, mimeType := 0, 0, ""
+    for sibling := n.NextSibling; sibling != nil; sibling = sibling.NextSibling {
+        if sibling.Type != html.ElementNode || sibling.Data != "meta" {
+            continue
+        }
+        attrMap := extractAttributes(sibling.Attr)
+        switch attrMap["property"] {
+        case "og:image":
+            return width, height, mimeType
+        case "og:image:width":
+            width, _ = strconv.Atoi(attrMap["content"])
+        case "og:image:height":
+            height, _ = strconv.Atoi(attrMap["content"])
+        case "og:image:type":
+            mimeType = attrMap["content"]
+        }
+    }
+    return width, height, mimeType
+}
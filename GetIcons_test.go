@@ -0,0 +1,137 @@
+package katsuragi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestGetIcons(t *testing.T) {
+    tests := []struct {
+        name          string
+        responseBody  string
+        expectedErr   string
+        expectedIcons []Icon
+    }{
+        {
+            name:         "No Icons",
+            responseBody: `<html><head></head><body></body></html>`,
+            expectedErr:  "GetIcons failed to find any icons",
+        },
+        {
+            name: "Link Tags",
+            responseBody: `<html><head>
+                <link rel="icon" type="image/png" href="/favicon-32.png" sizes="32x32">
+                <link rel="icon" type="image/svg+xml" href="/favicon.svg" sizes="any">
+                <link rel="apple-touch-icon" href="/apple-touch-icon.png" sizes="120x120 180X180" media="(prefers-color-scheme: dark)">
+                <link rel="icon" href="/favicon-32.png">
+                </head><body></body></html>`,
+            expectedIcons: []Icon{
+                {URL: "<serverURL>/favicon-32.png", Rel: "icon", Type: "image/png", Sizes: []IconSize{{32, 32}}, Source: IconSourceLink},
+                {URL: "<serverURL>/favicon.svg", Rel: "icon", Type: "image/svg+xml", AnySize: true, Source: IconSourceLink},
+                {URL: "<serverURL>/apple-touch-icon.png", Rel: "apple-touch-icon", Sizes: []IconSize{{120, 120}, {180, 180}}, Media: "(prefers-color-scheme: dark)", Source: IconSourceLink},
+            },
+        },
+        {
+            name: "Extended Rels And Windows Tiles",
+            responseBody: `<html><head>
+                <link rel="mask-icon" href="/safari-pinned-tab.svg" color="#5bbad5">
+                <link rel="fluid-icon" href="/fluidicon.png">
+                <link rel="Alternate Icon" href="/favicon.ico">
+                <meta name="msapplication-TileColor" content="#da532c">
+                <meta name="msapplication-TileImage" content="/mstile-144x144.png">
+                </head><body></body></html>`,
+            expectedIcons: []Icon{
+                {URL: "<serverURL>/safari-pinned-tab.svg", Rel: "mask-icon", Color: "#5bbad5", Source: IconSourceLink},
+                {URL: "<serverURL>/fluidicon.png", Rel: "fluid-icon", Source: IconSourceLink},
+                {URL: "<serverURL>/favicon.ico", Rel: "alternate icon", Source: IconSourceLink},
+                {URL: "<serverURL>/mstile-144x144.png", Sizes: []IconSize{{144, 144}}, Color: "#da532c", Source: IconSourceMSApplication},
+            },
+        },
+        {
+            name:         "OG Image Tag - 1:1 Aspect Ratio",
+            responseBody: `<html><head><meta property="og:image" content="og-image.png"><meta property="og:image:type" content="image/png"><meta property="og:image:width" content="1200"><meta property="og:image:height" content="1200"></head><body></body></html>`,
+            expectedIcons: []Icon{
+                {URL: "<serverURL>/og-image.png", Type: "image/png", Sizes: []IconSize{{1200, 1200}}, Source: IconSourceOpenGraph},
+            },
+        },
+        {
+            name:         "OG Image Tag - Non 1:1 Aspect Ratio",
+            responseBody: `<html><head><meta property="og:image" content="og-image.png"><meta property="og:image:width" content="1200"><meta property="og:image:height" content="630"></head><body></body></html>`,
+            expectedErr:  "GetIcons failed to find any icons",
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            f := NewFetcher(&FetcherProps{Timeout: 3000, CacheCap: 10})
+            defer f.ClearCache()
+            server := MockServer(t, tt.responseBody)
+            defer server.Close()
+
+            icons, err := f.GetIcons(server.URL)
+            if tt.expectedErr != "" {
+                if err == nil || err.Error() != tt.expectedErr {
+                    t.Fatalf("Expected error %q, got %v", tt.expectedErr, err)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("Expected no error, got: %v", err)
+            }
+            if len(icons) != len(tt.expectedIcons) {
+                t.Fatalf("Expected %d icons, got %d: %+v", len(tt.expectedIcons), len(icons), icons)
+            }
+            for i, expected := range tt.expectedIcons {
+                expected.URL = server.URL + expected.URL[len("<serverURL>"):]
+                if !reflect.DeepEqual(icons[i], expected) {
+                    t.Errorf("Expected icon %+v, got %+v", expected, icons[i])
+                }
+            }
+        })
+    }
+}
+
+func TestGetIcons_RootFavicon(t *testing.T) {
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path == "/favicon.ico" {
+            w.WriteHeader(http.StatusOK)
+            return
+        }
+        w.Header().Set("Content-Type", "text/html")
+        w.Write([]byte(`<html><head></head><body></body></html>`))
+    }))
+    defer server.Close()
+
+    f := NewFetcher(nil)
+    icons, err := f.GetIcons(server.URL)
+    if err != nil {
+        t.Fatalf("Expected no error, got: %v", err)
+    }
+    if len(icons) != 1 || icons[0].URL != server.URL+"/favicon.ico" || icons[0].Source != IconSourceRoot {
+        t.Fatalf("Expected root favicon.ico, got %+v", icons)
+    }
+}
+
+func TestParseIconSizes(t *testing.T) {
+    tests := []struct {
+        value           string
+        expectedSizes   []IconSize
+        expectedAnySize bool
+    }{
+        {"", nil, false},
+        {"16x16", []IconSize{{16, 16}}, false},
+        {"16x16 32X32", []IconSize{{16, 16}, {32, 32}}, false},
+        {"any", nil, true},
+        {"any 48x48", []IconSize{{48, 48}}, true},
+        {"16 axb 0x0 -1x5", nil, false},
+    }
+
+    for _, tt := range tests {
+        sizes, anySize := parseIconSizes(tt.value)
+        if !reflect.DeepEqual(sizes, tt.expectedSizes) || anySize != tt.expectedAnySize {
+            t.Errorf("parseIconSizes(%q) = %v, %v; want %v, %v", tt.value, sizes, anySize, tt.expectedSizes, tt.expectedAnySize)
+        }
+    }
+}
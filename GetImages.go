@@ -0,0 +1,172 @@
+package katsuragi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// lazySrcAttributes and lazySrcsetAttributes hold the real URLs of images loaded by lazy-loading scripts
+var (
+    lazySrcAttributes    = []string{"data-src", "data-lazy-src", "data-original"}
+    lazySrcsetAttributes = []string{"data-srcset", "data-lazy-srcset"}
+)
+
+// GetImages fetches the inventory of the images of the given URL in document order: <img> tags, <picture> sources,
+// url() of inline styles (background images) and og:image/twitter:image meta tags. URLs are resolved, including
+// srcset candidates and lazy-loading variants (data-src, data-srcset). <img> tags without any URL, without an alt
+// attribute or without declared width and height are flagged, see ImageIssue.
+func (f *Fetcher) GetImages(url string) ([]Image, error) {
+    document, err := retrieveDocument(url, f)
+    if err != nil {
+        return nil, err
+    }
+    images := extractImages(document.root, url)
+    if len(images) == 0 {
+        return nil, fmt.Errorf("GetImages failed to find images in HTML")
+    }
+    return images, nil
+}
+
+// Images extracts the images of the document, see GetImages
+func (d *Document) Images() ([]Image, error) {
+    images := extractImages(d.root, d.url)
+    if len(images) == 0 {
+        return nil, fmt.Errorf("Images failed to find images in HTML")
+    }
+    return images, nil
+}
+
+// extractImages traverses the HTML node tree and collects the images
+func extractImages(doc *html.Node, url string) []Image {
+    var images []Image
+    // og:image and twitter:image often repeat the same image
+    metaImages := make(map[string]bool)
+    add := func(image Image) {
+        image.Position = len(images)
+        images = append(images, image)
+    }
+
+    var traverse func(*html.Node)
+    traverse = func(n *html.Node) {
+        if n.Type == html.ElementNode {
+            attrMap := extractAttributes(n.Attr)
+            switch {
+            case n.Data == "img":
+                add(extractImage(n, attrMap, url))
+            case n.Data == "source" && n.Parent != nil && n.Parent.Data == "picture":
+                image := extractImage(n, attrMap, url)
+                image.Source = ImageSourcePicture
+                image.Media = strings.TrimSpace(attrMap["media"])
+                if image.URL != "" {
+                    add(image)
+                }
+            case n.Data == "meta":
+                key := strings.ToLower(attrMap["property"])
+                if key == "" {
+                    key = strings.ToLower(attrMap["name"])
+                }
+                content := strings.TrimSpace(attrMap["content"])
+                if (key == string(ImageSourceOg) || key == string(ImageSourceTwitter)) && content != "" {
+                    if imageURL := ensureAbsoluteURL(content, url); !metaImages[imageURL] {
+                        metaImages[imageURL] = true
+                        add(Image{URL: imageURL, Source: ImageSource(key)})
+                    }
+                }
+            }
+            if style, found := attrMap["style"]; found {
+                for _, cssURL := range extractCSSURLs(style) {
+                    if cssURL = strings.TrimSpace(cssURL); cssURL != "" {
+                        add(Image{URL: ensureAbsoluteURL(cssURL, url), Source: ImageSourceCSS})
+                    }
+                }
+            }
+        }
+        for c := n.FirstChild; c != nil; c = c.NextSibling {
+            traverse(c)
+        }
+    }
+    traverse(doc)
+    return images
+}
+
+// extractImage returns the image of an <img> tag (or <picture> source) and flags its issues
+func extractImage(n *html.Node, attrMap map[string]string, url string) Image {
+    image := Image{
+        Source:  ImageSourceImg,
+        Sizes:   strings.TrimSpace(attrMap["sizes"]),
+        Loading: strings.ToLower(strings.TrimSpace(attrMap["loading"])),
+        Width:   parseImageDimension(attrMap["width"]),
+        Height:  parseImageDimension(attrMap["height"]),
+        Srcset:  resolveSrcset(attrMap["srcset"], url),
+    }
+    image.Alt, image.HasAlt = attrMap["alt"]
+    image.Alt = strings.TrimSpace(image.Alt)
+    for _, attr := range lazySrcAttributes {
+        if src := strings.TrimSpace(attrMap[attr]); src != "" {
+            image.LazySrc = ensureAbsoluteURL(src, url)
+            break
+        }
+    }
+    for _, attr := range lazySrcsetAttributes {
+        if srcset := resolveSrcset(attrMap[attr], url); len(srcset) > 0 {
+            image.LazySrcset = srcset
+            break
+        }
+    }
+    // images without src are displayed from their srcset, or loaded by a lazy-loading script.
+    // A data: URI src next to lazy-loading attributes or a srcset is only a placeholder.
+    src := strings.TrimSpace(attrMap["src"])
+    placeholder := strings.HasPrefix(strings.ToLower(src), "data:") && (image.LazySrc != "" || len(image.LazySrcset) > 0 || len(image.Srcset) > 0)
+    switch {
+    case src != "" && !placeholder:
+        image.URL = ensureAbsoluteURL(src, url)
+    case placeholder && image.LazySrc != "":
+        image.URL = image.LazySrc
+    case placeholder && len(image.LazySrcset) > 0:
+        image.URL = image.LazySrcset[0].URL
+    case len(image.Srcset) > 0:
+        image.URL = image.Srcset[0].URL
+    case image.LazySrc != "":
+        image.URL = image.LazySrc
+    case len(image.LazySrcset) > 0:
+        image.URL = image.LazySrcset[0].URL
+    }
+
+    if n.Data == "img" {
+        if image.URL == "" {
+            image.Issues = append(image.Issues, ImageIssueMissingSrc)
+        }
+        if !image.HasAlt {
+            image.Issues = append(image.Issues, ImageIssueMissingAlt)
+        }
+        if image.Width == 0 || image.Height == 0 {
+            image.Issues = append(image.Issues, ImageIssueMissingDimensions)
+        }
+    }
+    return image
+}
+
+// resolveSrcset parses a srcset attribute and resolves the URLs of its candidates
+func resolveSrcset(srcset string, url string) []SrcsetCandidate {
+    candidates := parseSrcset(srcset)
+    for i := range candidates {
+        candidates[i].URL = ensureAbsoluteURL(candidates[i].URL, url)
+    }
+    return candidates
+}
+
+// parseImageDimension parses a width or height attribute ("640", "640px"), 0 if invalid
+func parseImageDimension(value string) int {
+    value = strings.TrimSuffix(strings.TrimSpace(value), "px")
+    if number, _, found := strings.Cut(value, "."); found {
+        value = number
+    }
+    dimension, err := strconv.Atoi(value)
+    if err != nil || dimension < 0 {
+        return 0
+    }
+    return dimension
+}
@@ -0,0 +1,108 @@
+package katsuragi
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetImages(t *testing.T) {
+    server := MockServer(t, `<html><head>
+        <meta property="og:image" content="/og.png">
+        <meta name="twitter:image" content="https://cdn.example.com/card.png">
+        <meta name="twitter:image" content="/og.png">
+        </head><body>
+        <img src="/logo.png" alt="Logo" width="120" height="40">
+        <img src="photo.jpg" srcset="photo-640.jpg 640w, photo-1280.jpg 1280w" sizes="100vw" alt="" loading="LAZY">
+        <img src="data:image/gif;base64,R0lGODlhAQABAAAAACw=" data-src="/lazy.jpg" data-srcset="/lazy-2x.jpg 2x" height="100px">
+        <picture>
+            <source media="(min-width: 800px)" srcset="/wide.webp 1x, /wide-2x.webp 2x" width="800" height="400">
+            <img src="/narrow.jpg" alt="Narrow" width="400" height="400">
+        </picture>
+        <div style="background-image: url('/hero.jpg')"></div>
+        </body></html>`)
+    defer server.Close()
+
+    images, err := NewFetcher(nil).GetImages(server.URL)
+    if err != nil {
+        t.Fatalf("GetImages() error = %v", err)
+    }
+    expected := []Image{
+        {URL: server.URL + "/og.png", Source: ImageSourceOg, Position: 0},
+        {URL: "https://cdn.example.com/card.png", Source: ImageSourceTwitter, Position: 1},
+        {URL: server.URL + "/logo.png", Source: ImageSourceImg, Alt: "Logo", HasAlt: true, Width: 120, Height: 40, Position: 2},
+        {
+            URL: server.URL + "/photo.jpg", Source: ImageSourceImg, HasAlt: true, Loading: "lazy", Sizes: "100vw", Position: 3,
+            Srcset: []SrcsetCandidate{{URL: server.URL + "/photo-640.jpg", Descriptor: "640w"}, {URL: server.URL + "/photo-1280.jpg", Descriptor: "1280w"}},
+            Issues: []ImageIssue{ImageIssueMissingDimensions},
+        },
+        {
+            URL: server.URL + "/lazy.jpg", Source: ImageSourceImg, Height: 100, Position: 4,
+            LazySrc: server.URL + "/lazy.jpg", LazySrcset: []SrcsetCandidate{{URL: server.URL + "/lazy-2x.jpg", Descriptor: "2x"}},
+            Issues: []ImageIssue{ImageIssueMissingAlt, ImageIssueMissingDimensions},
+        },
+        {
+            URL: server.URL + "/wide.webp", Source: ImageSourcePicture, Width: 800, Height: 400, Media: "(min-width: 800px)", Position: 5,
+            Srcset: []SrcsetCandidate{{URL: server.URL + "/wide.webp", Descriptor: "1x"}, {URL: server.URL + "/wide-2x.webp", Descriptor: "2x"}},
+        },
+        {URL: server.URL + "/narrow.jpg", Source: ImageSourceImg, Alt: "Narrow", HasAlt: true, Width: 400, Height: 400, Position: 6},
+        {URL: server.URL + "/hero.jpg", Source: ImageSourceCSS, Position: 7},
+    }
+    if len(images) != len(expected) {
+        t.Fatalf("Expected %v images, got %v: %+v", len(expected), len(images), images)
+    }
+    for i := range expected {
+        if !reflect.DeepEqual(images[i], expected[i]) {
+            t.Errorf("Image %v:\nexpected %+v\ngot      %+v", i, expected[i], images[i])
+        }
+    }
+}
+
+func TestDocumentImages(t *testing.T) {
+    doc, _ := ParseString(`<html><body><img data-lazy-src="/lazy.png" alt="Lazy" width="10" height="10"></body></html>`, "https://www.example.com/page")
+    images, err := doc.Images()
+    if err != nil {
+        t.Fatalf("Images() error = %v", err)
+    }
+    if len(images) != 1 || images[0].URL != "https://www.example.com/lazy.png" || images[0].LazySrc != images[0].URL || images[0].Issues != nil {
+        t.Errorf("Unexpected images %+v", images)
+    }
+
+    // images without src fall back to their srcset, then to their lazy-loading variants
+    doc, _ = ParseString(`<html><body>
+        <img srcset="/a-1x.png 1x, /a-2x.png 2x" alt="A" width="10" height="10">
+        <img data-srcset="/b-1x.png 1x" alt="B" width="10" height="10">
+        <img alt="C" width="10" height="10">
+        </body></html>`, "https://www.example.com/page")
+    images, _ = doc.Images()
+    if len(images) != 3 || images[0].URL != "https://www.example.com/a-1x.png" || images[1].URL != "https://www.example.com/b-1x.png" {
+        t.Fatalf("Unexpected images %+v", images)
+    }
+    if images[0].Issues != nil || images[1].Issues != nil || images[2].URL != "" || !reflect.DeepEqual(images[2].Issues, []ImageIssue{ImageIssueMissingSrc}) {
+        t.Errorf("Unexpected issues %+v", images)
+    }
+
+    // data: URI placeholders are replaced by the lazy-loading URL or the srcset, data: URIs alone are kept
+    doc, _ = ParseString(`<html><body>
+        <img src="data:image/gif;base64,R0lGODlhAQABAAAAACw=" data-lazy-srcset="/c-1x.png 1x" alt="C" width="10" height="10">
+        <img src="data:image/svg+xml,%3Csvg%3E%3C/svg%3E" srcset="/d-1x.png 1x, /d-2x.png 2x" alt="D" width="10" height="10">
+        <img src="data:image/png;base64,iVBORw0KGgo=" alt="E" width="10" height="10">
+        </body></html>`, "https://www.example.com/page")
+    images, _ = doc.Images()
+    if len(images) != 3 || images[0].URL != "https://www.example.com/c-1x.png" || images[1].URL != "https://www.example.com/d-1x.png" || images[2].URL != "data:image/png;base64,iVBORw0KGgo=" {
+        t.Errorf("Unexpected images %+v", images)
+    }
+
+    empty, _ := ParseString(`<html><body><p>No images</p></body></html>`, "https://www.example.com")
+    if _, err := empty.Images(); err == nil || err.Error() != "Images failed to find images in HTML" {
+        t.Errorf("Expected error, got %v", err)
+    }
+}
+
+func TestParseImageDimension(t *testing.T) {
+    tests := map[string]int{"640": 640, " 480px ": 480, "100.5": 100, "50%": 0, "": 0, "-1": 0, "auto": 0}
+    for value, expected := range tests {
+        if dimension := parseImageDimension(value); dimension != expected {
+            t.Errorf("parseImageDimension(%q) = %v, expected %v", value, dimension, expected)
+        }
+    }
+}
@@ -0,0 +1,219 @@
+package katsuragi
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+)
+
+// GetLanguage fetches the language of the given URL declared with <html lang>, the Content-Language header,
+// <meta http-equiv="content-language"> or og:locale, in this order of precedence. When no language is declared,
+// the language is detected from the stopwords and the scripts of the visible text.
+func (f *Fetcher) GetLanguage(url string) (*Language, error) {
+    document, err := retrieveDocument(url, f)
+    if err != nil {
+        return nil, err
+    }
+    language, found := extractLanguage(document.root, document.responseHeader())
+    if !found {
+        return nil, fmt.Errorf("GetLanguage failed to find language in HTML")
+    }
+    return language, nil
+}
+
+// Language extracts the language of the document, see GetLanguage
+func (d *Document) Language() (*Language, error) {
+    language, found := extractLanguage(d.root, d.responseHeader())
+    if !found {
+        return nil, fmt.Errorf("Language failed to find language in HTML")
+    }
+    return language, nil
+}
+
+// extractLanguage returns the declared language of the document, falling back to the detected one
+func extractLanguage(doc *html.Node, header http.Header) (*Language, bool) {
+    var htmlLang, metaLang, ogLocale string
+    var traverse func(*html.Node)
+    traverse = func(n *html.Node) {
+        if n.Type == html.ElementNode {
+            attrMap := extractAttributes(n.Attr)
+            switch n.Data {
+            case "html":
+                htmlLang = attrMap["lang"]
+                if strings.TrimSpace(htmlLang) == "" {
+                    htmlLang = attrMap["xml:lang"]
+                }
+            case "meta":
+                if metaLang == "" && strings.EqualFold(attrMap["http-equiv"], "content-language") {
+                    metaLang = attrMap["content"]
+                } else if ogLocale == "" && strings.EqualFold(attrMap["property"], "og:locale") {
+                    ogLocale = attrMap["content"]
+                }
+            case "body":
+                // metadata lives in the head
+                return
+            }
+        }
+        for c := n.FirstChild; c != nil; c = c.NextSibling {
+            traverse(c)
+        }
+    }
+    traverse(doc)
+
+    declared := []struct {
+        value  string
+        source LanguageSource
+    }{
+        {htmlLang, LanguageSourceHTML},
+        {header.Get("Content-Language"), LanguageSourceHeader},
+        {metaLang, LanguageSourceMeta},
+        {ogLocale, LanguageSourceOgLocale},
+    }
+    for _, candidate := range declared {
+        // Content-Language and http-equiv may list several languages, the first one is the main language
+        value, _, _ := strings.Cut(candidate.value, ",")
+        if language, ok := parseLanguageTag(value); ok {
+            language.Source = candidate.source
+            language.Confidence = 1
+            return language, true
+        }
+    }
+    return detectLanguage(extractVisibleText(doc))
+}
+
+// parseLanguageTag normalizes a language tag such as "en", "EN-us" or "en_US" (og:locale) into a Language
+func parseLanguageTag(value string) (*Language, bool) {
+    parsed := parseHreflang(strings.TrimSpace(value))
+    if len(parsed.Language) < 2 || len(parsed.Language) > 3 || !isASCIILetters(parsed.Language) {
+        return nil, false
+    }
+    tag := parsed.Language
+    if parsed.Script != "" {
+        tag += "-" + parsed.Script
+    }
+    if parsed.Region != "" {
+        tag += "-" + parsed.Region
+    }
+    return &Language{Tag: tag, Language: parsed.Language, Region: parsed.Region}, true
+}
+
+func isASCIILetters(value string) bool {
+    for _, r := range value {
+        if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+            return false
+        }
+    }
+    return true
+}
+
+// languageStopwords are frequent words of the languages told apart by their stopwords
+var languageStopwords = map[string][]string{
+    "en": {"the", "and", "of", "to", "is", "in", "that", "it", "for", "with", "as", "was", "on", "are", "you", "this", "be", "at", "by", "not", "or", "have", "from", "but", "which", "they", "we", "an", "their", "has"},
+    "de": {"der", "die", "und", "das", "ist", "nicht", "mit", "den", "ein", "eine", "sich", "auf", "für", "dem", "des", "im", "auch", "es", "von", "zu", "sie", "wir", "ich", "wird", "oder", "aber", "werden", "nach", "bei", "sind"},
+    "fr": {"le", "la", "les", "et", "des", "est", "une", "un", "du", "dans", "que", "pour", "qui", "pas", "sur", "au", "avec", "il", "ce", "sont", "nous", "vous", "par", "mais", "ou", "aux", "cette", "elle", "ne", "se"},
+    "es": {"el", "la", "los", "las", "y", "que", "es", "en", "por", "con", "una", "para", "del", "se", "no", "al", "como", "más", "pero", "sus", "le", "ya", "o", "este", "sí", "porque", "esta", "entre", "cuando", "muy"},
+    "it": {"il", "di", "che", "la", "è", "e", "per", "un", "una", "non", "sono", "del", "della", "con", "gli", "le", "si", "da", "questo", "nel", "alla", "anche", "come", "più", "ma", "ci", "lo", "dei", "delle", "essere"},
+    "pt": {"de", "que", "não", "uma", "os", "do", "da", "em", "para", "com", "um", "é", "no", "na", "as", "se", "por", "mais", "dos", "das", "como", "mas", "foi", "ao", "ele", "ela", "à", "seu", "sua", "são"},
+    "nl": {"de", "het", "een", "en", "van", "is", "dat", "niet", "op", "te", "zijn", "voor", "met", "die", "er", "aan", "ook", "als", "maar", "bij", "om", "dan", "wordt", "nog", "naar", "uit", "ze", "wij", "worden", "hebben"},
+    "sv": {"och", "att", "det", "som", "en", "är", "på", "för", "med", "av", "inte", "den", "till", "har", "de", "om", "ett", "var", "men", "från", "jag", "vi", "så", "kan", "eller", "hon", "han", "när", "sig", "också"},
+    "pl": {"i", "w", "nie", "na", "się", "z", "do", "to", "że", "jest", "jak", "o", "co", "ale", "po", "tak", "od", "za", "przez", "dla", "są", "już", "jego", "czy", "tym", "tylko", "może", "być", "jej", "oraz"},
+    "ru": {"и", "в", "не", "на", "что", "с", "по", "это", "как", "из", "но", "он", "к", "для", "от", "так", "же", "все", "она", "его", "был", "то", "за", "бы", "мы", "вы", "они", "при", "или", "уже"},
+    "uk": {"і", "в", "не", "на", "що", "з", "та", "це", "як", "до", "але", "він", "для", "від", "так", "її", "його", "була", "був", "ми", "ви", "вони", "при", "або", "вже", "цей", "також", "які", "щоб", "є"},
+}
+
+// scriptLanguages maps scripts used by a single language (or a dominant one) to its code
+var scriptLanguages = []struct {
+    table    *unicode.RangeTable
+    language string
+}{
+    {unicode.Hiragana, "ja"},
+    {unicode.Katakana, "ja"},
+    {unicode.Hangul, "ko"},
+    {unicode.Han, "zh"},
+    {unicode.Greek, "el"},
+    {unicode.Hebrew, "he"},
+    {unicode.Arabic, "ar"},
+    {unicode.Thai, "th"},
+    {unicode.Devanagari, "hi"},
+    {unicode.Georgian, "ka"},
+    {unicode.Armenian, "hy"},
+}
+
+const (
+    // minDetectionWords is the minimum number of words needed to detect a language from its stopwords
+    minDetectionWords = 20
+    // minScriptLetters is the minimum number of letters needed to detect a language from its script
+    minScriptLetters = 20
+)
+
+// detectLanguage detects the language of the text. Languages with a distinctive script (Japanese, Korean, Greek...)
+// are detected from their letters, others from their stopwords. The confidence is the share of the matched
+// letters or stopwords belonging to the detected language.
+func detectLanguage(text string) (*Language, bool) {
+    // distinctive scripts first. Japanese mixes Han with kana, so kana outweighs Han
+    scriptCounts := make(map[string]int)
+    letters := 0
+    for _, r := range text {
+        if !unicode.IsLetter(r) {
+            continue
+        }
+        letters++
+        for _, script := range scriptLanguages {
+            if unicode.Is(script.table, r) {
+                scriptCounts[script.language]++
+                break
+            }
+        }
+    }
+    if scriptCounts["ja"] > 0 && scriptCounts["ja"]*10 >= scriptCounts["zh"] {
+        scriptCounts["ja"] += scriptCounts["zh"]
+        delete(scriptCounts, "zh")
+    }
+    if language, count := bestLanguage(scriptCounts); count >= minScriptLetters && count*2 > letters {
+        return &Language{Tag: language, Language: language, Source: LanguageSourceDetected, Confidence: float64(count) / float64(letters)}, true
+    }
+
+    words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+        return !unicode.IsLetter(r) && r != '\''
+    })
+    if len(words) < minDetectionWords {
+        return nil, false
+    }
+    stopwordCounts := make(map[string]int)
+    for _, word := range words {
+        for language, stopwords := range languageStopwords {
+            if contains(stopwords, word) {
+                stopwordCounts[language]++
+            }
+        }
+    }
+    language, count := bestLanguage(stopwordCounts)
+    if count == 0 {
+        return nil, false
+    }
+    total := 0
+    for _, languageCount := range stopwordCounts {
+        total += languageCount
+    }
+    return &Language{Tag: language, Language: language, Source: LanguageSourceDetected, Confidence: float64(count) / float64(total)}, true
+}
+
+// bestLanguage returns the language with the highest count, ties being broken alphabetically
+func bestLanguage(counts map[string]int) (string, int) {
+    languages := make([]string, 0, len(counts))
+    for language := range counts {
+        languages = append(languages, language)
+    }
+    sort.Strings(languages)
+    best, bestCount := "", 0
+    for _, language := range languages {
+        if counts[language] > bestCount {
+            best, bestCount = language, counts[language]
+        }
+    }
+    return best, bestCount
+}
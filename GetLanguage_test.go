@@ -0,0 +1,148 @@
+package katsuragi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const englishText = `The quick brown fox jumps over the lazy dog. It is one of the most famous sentences in the English language,
+    and it has been used for many years to test typewriters and computer keyboards because it contains all of the letters.`
+
+const germanText = `Der schnelle braune Fuchs springt über den faulen Hund. Das ist ein bekannter Satz, der auch in der deutschen
+    Sprache oft verwendet wird, weil er sich gut für Tests eignet und nicht zu lang ist. Wir haben ihn auf der Seite.`
+
+func TestDocumentLanguage(t *testing.T) {
+    tests := []struct {
+        name     string
+        html     string
+        expected *Language
+    }{
+        {
+            name:     "html lang",
+            html:     `<html lang="en-us"><head><meta property="og:locale" content="de_DE"></head><body></body></html>`,
+            expected: &Language{Tag: "en-US", Language: "en", Region: "US", Source: LanguageSourceHTML, Confidence: 1},
+        },
+        {
+            name:     "meta http-equiv",
+            html:     `<html><head><meta http-equiv="Content-Language" content="fr-CA, en"><meta property="og:locale" content="de_DE"></head></html>`,
+            expected: &Language{Tag: "fr-CA", Language: "fr", Region: "CA", Source: LanguageSourceMeta, Confidence: 1},
+        },
+        {
+            name:     "og:locale",
+            html:     `<html lang=""><head><meta property="og:locale" content="pt_BR"></head></html>`,
+            expected: &Language{Tag: "pt-BR", Language: "pt", Region: "BR", Source: LanguageSourceOgLocale, Confidence: 1},
+        },
+        {
+            name:     "Script",
+            html:     `<html lang="zh-Hant-TW"><body></body></html>`,
+            expected: &Language{Tag: "zh-Hant-TW", Language: "zh", Region: "TW", Source: LanguageSourceHTML, Confidence: 1},
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            doc, _ := ParseString(tt.html, "https://www.example.com")
+            language, err := doc.Language()
+            if err != nil {
+                t.Fatalf("Language() error = %v", err)
+            }
+            if !reflect.DeepEqual(language, tt.expected) {
+                t.Errorf("Language() = %+v, want %+v", language, tt.expected)
+            }
+        })
+    }
+
+    doc, _ := ParseString(`<html lang="invalid-tag-1"><body><p>Too short</p></body></html>`, "https://www.example.com")
+    if _, err := doc.Language(); err == nil || err.Error() != "Language failed to find language in HTML" {
+        t.Errorf("expected an error without language, got %v", err)
+    }
+}
+
+func TestDetectLanguage(t *testing.T) {
+    tests := []struct {
+        name     string
+        text     string
+        expected string
+    }{
+        {"English", englishText, "en"},
+        {"German", germanText, "de"},
+        {"French", "Le renard brun rapide saute par-dessus le chien paresseux. C'est une phrase connue qui est utilisée dans les tests pour vérifier que les lettres sont toutes présentes sur le clavier.", "fr"},
+        {"Spanish", "El rápido zorro marrón salta sobre el perro perezoso. Es una frase muy conocida que se usa en las pruebas para ver que todas las letras están en el teclado, porque es corta.", "es"},
+        {"Russian", "Съешь же ещё этих мягких французских булок, да выпей чаю. Это известная фраза, которая используется для проверки шрифтов, так как в ней есть все буквы, и она не очень длинная.", "ru"},
+        {"Japanese", "いろはにほへと ちりぬるを わかよたれそ つねならむ うゐのおくやま けふこえて あさきゆめみし ゑひもせす 日本語の文章です。", "ja"},
+        {"Chinese", "我能吞下玻璃而不伤身体。这是一个用来测试字体的句子，在很多地方都可以看到它。", "zh"},
+        {"Korean", "다람쥐 헌 쳇바퀴에 타고파. 이 문장은 한국어 글꼴을 시험하기 위해 자주 사용됩니다.", "ko"},
+        {"Greek", "Ξεσκεπάζω την ψυχοφθόρα βδελυγμία. Αυτή η φράση χρησιμοποιείται για δοκιμές γραμματοσειρών.", "el"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            language, found := detectLanguage(tt.text)
+            if !found {
+                t.Fatalf("detectLanguage() did not detect any language")
+            }
+            if language.Language != tt.expected || language.Source != LanguageSourceDetected || language.Confidence <= 0 || language.Confidence > 1 {
+                t.Errorf("detectLanguage() = %+v, want %v", language, tt.expected)
+            }
+        })
+    }
+
+    if language, found := detectLanguage("Hello world"); found {
+        t.Errorf("expected no language for a short text, got %+v", language)
+    }
+}
+
+func TestGetLanguage(t *testing.T) {
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Header().Set("Content-Type", "text/html")
+        switch r.URL.Path {
+        case "/header":
+            w.Header().Set("Content-Language", "de-AT")
+            w.Write([]byte(`<html><head><meta property="og:locale" content="en_US"></head><body></body></html>`))
+        case "/detected":
+            // hidden and head text is ignored
+            w.Write([]byte(`<html><head><title>` + strings.Repeat("der die das ", 20) + `</title></head><body><p>` + englishText +
+                `</p><div hidden>` + germanText + `</div><div style="display: none">` + germanText + `</div></body></html>`))
+        default:
+            w.Write([]byte(`<html><body>Nothing</body></html>`))
+        }
+    }))
+    defer server.Close()
+
+    language, err := NewFetcher(nil).GetLanguage(server.URL + "/header")
+    if err != nil || language.Tag != "de-AT" || language.Source != LanguageSourceHeader {
+        t.Errorf("GetLanguage() = %+v, %v", language, err)
+    }
+    language, err = NewFetcher(nil).GetLanguage(server.URL + "/detected")
+    if err != nil || language.Tag != "en" || language.Source != LanguageSourceDetected {
+        t.Errorf("GetLanguage() = %+v, %v", language, err)
+    }
+    if _, err := NewFetcher(nil).GetLanguage(server.URL + "/none"); err == nil || err.Error() != "GetLanguage failed to find language in HTML" {
+        t.Errorf("expected an error without language, got %v", err)
+    }
+}
+
+func TestGetMetadata(t *testing.T) {
+    server := headServer(t, `<title>Page</title><meta name="description" content="About the page">
+        <link rel="canonical" href="/canonical"><meta property="og:locale" content="en_GB">`, "")
+
+    metadata, err := NewFetcher(nil).GetMetadata(server.URL + "/page")
+    if err != nil {
+        t.Fatalf("GetMetadata() error = %v", err)
+    }
+    expected := &Metadata{
+        URL:         server.URL + "/page",
+        Title:       "Page",
+        Description: "About the page",
+        Canonical:   server.URL + "/canonical",
+        Language:    &Language{Tag: "en-GB", Language: "en", Region: "GB", Source: LanguageSourceOgLocale, Confidence: 1},
+    }
+    if !reflect.DeepEqual(metadata, expected) {
+        t.Errorf("GetMetadata() = %+v, want %+v", metadata, expected)
+    }
+
+    if _, err := NewFetcher(nil).GetMetadata(server.URL + "/missing"); err == nil {
+        t.Errorf("expected an error for a missing page")
+    }
+}
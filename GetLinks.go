@@ -3,15 +3,21 @@ package katsuragi
 import (
 	"fmt"
 	"net/url"
+	"sort"
+	"strings"
 
 	"golang.org/x/net/html"
 )
 
-// GetLinks fetches links from the given URL based on the category ("all", "internal", "external")
+// GetLinks fetches the resolved URLs of the links of the given URL based on the category (see LinkCategory),
+// the optional kinds and the optional filters, in document order unless Dedupe and Sort are set.
+// Without kinds, every link with a valid domain is returned, including #fragment links (resolved against the page URL)
+// and other schemes such as ftp://; set Kinds to []LinkKind{LinkKindWeb} for web links only.
+// Sorting by count requires Dedupe, see GetLinksProps.
 func (f *Fetcher) GetLinks(props GetLinksProps) ([]string, error) {
 	// Set default category to "all"
-	if props.Category == "" {
-		props.Category = "all"
+	if err := normalizeLinksProps(&props, "GetLinks"); err != nil {
+		return nil, err
 	}
 
     doc, err := retrieveHTML(props.Url, f)
@@ -19,51 +25,217 @@ func (f *Fetcher) GetLinks(props GetLinksProps) ([]string, error) {
 		return nil, err
 	}
 
+    links := extractLinks(doc, props)
+
+	if len(links) == 0 {
+		return nil, fmt.Errorf("GetTitle failed to find any links in HTML")
+	}
+
+    return links, nil
+}
+
+// GetLinkDetails fetches links from the given URL like GetLinks, but returns the details of every <a> tag:
+// href, resolved URL, kind, anchor text, rel tokens, title, target, hreflang, position and page section
+func (f *Fetcher) GetLinkDetails(props GetLinksProps) ([]Link, error) {
+    if err := normalizeLinksProps(&props, "GetLinkDetails"); err != nil {
+        return nil, err
+    }
+
+    doc, err := retrieveHTML(props.Url, f)
+    if err != nil {
+        return nil, err
+    }
+
+    links := extractLinkDetails(doc, props)
+
+    if len(links) == 0 {
+        return nil, fmt.Errorf("GetLinkDetails failed to find any links in HTML")
+    }
+
+    return links, nil
+}
+
+// LinkDetails extracts the details of the links of the document, see GetLinkDetails.
+// Links are resolved against the document URL unless props.Url is set.
+func (d *Document) LinkDetails(props GetLinksProps) ([]Link, error) {
+    if props.Url == "" {
+        props.Url = d.url
+    }
+    if err := normalizeLinksProps(&props, "LinkDetails"); err != nil {
+        return nil, err
+    }
+    links := extractLinkDetails(d.root, props)
+    if len(links) == 0 {
+        return nil, fmt.Errorf("LinkDetails failed to find any links in HTML")
+    }
+    return links, nil
+}
+
+var validLinkCategories = map[LinkCategory]bool{
+    LinkCategoryAll:           true,
+    LinkCategoryInternal:      true,
+    LinkCategoryExternal:      true,
+    LinkCategorySameHost:      true,
+    LinkCategorySameDomain:    true,
+    LinkCategorySameSubdomain: true,
+}
+
+// normalizeLinksProps sets the default category ("all") and validates the props.
+// Empty kinds are kept, see extractLinkDetails.
+func normalizeLinksProps(props *GetLinksProps, caller string) error {
+    if props.Category == "" {
+        props.Category = LinkCategoryAll
+    }
+    if !validLinkCategories[props.Category] {
+        return fmt.Errorf("%s failed to filter links. Invalid category: %v", caller, props.Category)
+    }
+    switch props.Dedupe {
+    case LinkDedupeNone, LinkDedupeExact, LinkDedupeNormalized:
+    default:
+        return fmt.Errorf("%s failed to deduplicate links. Invalid dedupe mode: %v", caller, props.Dedupe)
+    }
+    switch props.Sort {
+    case LinkSortDocument, LinkSortURL, LinkSortCount:
+    default:
+        return fmt.Errorf("%s failed to sort links. Invalid sort mode: %v", caller, props.Sort)
+    }
+    // occurrences are only counted when deduplicating
+    if props.Sort == LinkSortCount && props.Dedupe == LinkDedupeNone {
+        return fmt.Errorf("%s failed to sort links. Sorting by count requires a dedupe mode", caller)
+    }
+    return nil
+}
+
+// dedupeAndSortLinks merges duplicated links (keeping the first occurrence and counting occurrences)
+// and sorts them according to the props
+func dedupeAndSortLinks(links []Link, props GetLinksProps) []Link {
+    if props.Dedupe != LinkDedupeNone {
+        var unique []Link
+        index := make(map[string]int)
+        for _, link := range links {
+            key := link.URL
+            if props.Dedupe == LinkDedupeNormalized {
+                key = normalizeURL(link.URL)
+            }
+            if i, found := index[key]; found {
+                unique[i].Occurrences++
+                continue
+            }
+            link.Occurrences = 1
+            index[key] = len(unique)
+            unique = append(unique, link)
+        }
+        links = unique
+    }
+
+    switch props.Sort {
+    case LinkSortURL:
+        sort.SliceStable(links, func(i, j int) bool {
+            return links[i].URL < links[j].URL
+        })
+    case LinkSortCount:
+        sort.SliceStable(links, func(i, j int) bool {
+            return links[i].Occurrences > links[j].Occurrences
+        })
+    }
+    return links
+}
+
+// extractLinks traverses the HTML node tree and collects the resolved href of every <a> tag
+// which matches the props, see extractLinkDetails
+func extractLinks(doc *html.Node, props GetLinksProps) []string {
     var links []string
+    for _, link := range extractLinkDetails(doc, props) {
+        links = append(links, link.URL)
+    }
+    return links
+}
+
+// extractLinkDetails traverses the HTML node tree and collects the details of every <a> tag
+// which belongs to the category and one of the kinds and passes all filters of the props.
+// The props must be normalized, see normalizeLinksProps.
+func extractLinkDetails(doc *html.Node, props GetLinksProps) []Link {
+    var links []Link
+
+    baseUrl, err := url.Parse(props.Url)
+    if err != nil {
+        return nil
+    }
+    // base domain
+    baseUrlDomain := ""
+    if baseUrlParts, err := extractDomainParts(props.Url); err == nil {
+        baseUrlDomain = baseUrlParts.Root + "." + baseUrlParts.TLD
+    }
+    baseHost := strings.ToLower(baseUrl.Hostname())
+
+    // inCategory checks if a web link belongs to the category
+    inCategory := func(resolved *url.URL) bool {
+        // link domain
+        resolvedUrlParts, err := extractDomainParts(resolved.String())
+        if err != nil {
+            return false
+        }
+        resolvedUrlDomain := resolvedUrlParts.Root + "." + resolvedUrlParts.TLD
+        resolvedHost := strings.ToLower(resolved.Hostname())
 
-    baseUrl, _ := url.Parse(props.Url)
-    // *The error is ignored because the URL has been already validated in retrieveHTML.
+        // Url.host will be different in cases like "http://example.com" and "http://www.example.com",
+        // so we need to compare the domains instead.
+        switch props.Category {
+        case LinkCategoryInternal, LinkCategorySameDomain:
+            return resolvedUrlDomain == "" || resolvedUrlDomain == baseUrlDomain
+        case LinkCategoryExternal:
+            return resolvedUrlDomain != "" && resolvedUrlDomain != baseUrlDomain
+        case LinkCategorySameHost:
+            return resolvedHost == baseHost
+        case LinkCategorySameSubdomain:
+            return resolvedHost == baseHost || strings.HasSuffix(resolvedHost, "."+baseHost)
+        }
+        return true
+    }
 
+    position := 0
     var traverse func(*html.Node)
     traverse = func(n *html.Node) {
         if n.Type == html.ElementNode && n.Data == "a" {
-            for _, a := range n.Attr {
-                if a.Key == "href" {
-					// will be tested using bad links in html
-                    href, err := url.Parse(a.Val)
-                    if err != nil {
-                        continue
+            attrMap := extractAttributes(n.Attr)
+            if rawHref, found := attrMap["href"]; found {
+                position++
+                // will be tested using bad links in html
+                if href, err := url.Parse(strings.TrimSpace(rawHref)); err == nil {
+                    // absolute href (if relative, returns the same if not)
+                    resolved := baseUrl.ResolveReference(href)
+                    kind := classifyLink(rawHref, resolved)
+
+                    // categories only apply to web links, which must have a valid domain.
+                    // Without kinds, every link with a valid domain in the category is kept, as GetLinks always did
+                    // (web links, but also fragments and other schemes such as ftp://).
+                    var matches bool
+                    if len(props.Kinds) == 0 {
+                        matches = inCategory(resolved)
+                    } else {
+                        matches = contains(props.Kinds, kind) && (kind != LinkKindWeb || inCategory(resolved))
                     }
-					// absolute href (if relative, returns the same if not)
-					resolvedUrl := baseUrl.ResolveReference(href).String()
-					// base domain
-					baseUrlParts, _ := extractDomainParts(props.Url)
-					// *The error is ignored because the URL has been already validated in retrieveHTML.
-					// link domain
-					resolvedUrlParts, err := extractDomainParts(resolvedUrl)
-					if err != nil {
-						return
-					}
-					baseUrlDomain := baseUrlParts.Root + "." + baseUrlParts.TLD
-					resolvedUrlDomain := resolvedUrlParts.Root + "." + resolvedUrlParts.TLD
-
-
-					// Url.host will be different in cases like "http://example.com" and "http://www.example.com",
-					// so we need to compare the domains instead.
-
-                    switch props.Category {
-                    case "all":
-                        links = append(links, resolvedUrl)
-                    case "internal":
-                        if resolvedUrlDomain == "" || resolvedUrlDomain == baseUrlDomain {
-                            links = append(links, resolvedUrl)
+                    if matches {
+                        var rel []string
+                        if relAttr := strings.ToLower(attrMap["rel"]); strings.TrimSpace(relAttr) != "" {
+                            rel = strings.Fields(relAttr)
+                        }
+                        link := Link{
+                            Href:     rawHref,
+                            URL:      resolved.String(),
+                            Kind:     kind,
+                            Text:     extractAnchorText(n),
+                            Rel:      rel,
+                            Title:    attrMap["title"],
+                            Target:   attrMap["target"],
+                            Hreflang: attrMap["hreflang"],
+                            Position: position,
+                            Section:  linkSection(n),
                         }
-                    case "external":
-                        if resolvedUrlDomain != "" && resolvedUrlDomain != baseUrlDomain {
-                            links = append(links, resolvedUrl)
+                        if matchLinkFilters(link, props.Filters) {
+                            links = append(links, link)
                         }
                     }
-                    break
                 }
             }
         }
@@ -74,9 +246,78 @@ func (f *Fetcher) GetLinks(props GetLinksProps) ([]string, error) {
 
     traverse(doc)
 
-	if len(links) == 0 {
-		return nil, fmt.Errorf("GetTitle failed to find any links in HTML")
-	}
+    return dedupeAndSortLinks(links, props)
+}
 
-    return links, nil
+// classifyLink returns the kind of a link based on its raw href and its resolved URL
+func classifyLink(rawHref string, resolved *url.URL) LinkKind {
+    if strings.HasPrefix(strings.TrimSpace(rawHref), "#") {
+        return LinkKindFragment
+    }
+    switch strings.ToLower(resolved.Scheme) {
+    case "http", "https":
+        return LinkKindWeb
+    case "mailto":
+        return LinkKindEmail
+    case "tel":
+        return LinkKindPhone
+    case "javascript":
+        return LinkKindScript
+    }
+    return LinkKindOther
+}
+
+// extractAnchorText returns the text of a link with collapsed whitespace.
+// Links without text (e.g. image links) fall back to the alt attribute of their images, then to aria-label.
+func extractAnchorText(n *html.Node) string {
+    var text, alt []string
+    var collect func(*html.Node)
+    collect = func(n *html.Node) {
+        if n.Type == html.TextNode {
+            text = append(text, n.Data)
+        } else if n.Type == html.ElementNode && n.Data == "img" {
+            if altText := strings.TrimSpace(extractAttributes(n.Attr)["alt"]); altText != "" {
+                alt = append(alt, altText)
+            }
+        }
+        for c := n.FirstChild; c != nil; c = c.NextSibling {
+            collect(c)
+        }
+    }
+    collect(n)
+
+    if anchorText := strings.Join(strings.Fields(strings.Join(text, " ")), " "); anchorText != "" {
+        return anchorText
+    }
+    if len(alt) > 0 {
+        return strings.Join(alt, " ")
+    }
+    return strings.Join(strings.Fields(extractAttributes(n.Attr)["aria-label"]), " ")
+}
+
+// sectionRoles maps ARIA landmark roles to the equivalent sectioning elements
+var sectionRoles = map[string]string{
+    "navigation":    "nav",
+    "banner":        "header",
+    "contentinfo":   "footer",
+    "main":          "main",
+    "complementary": "aside",
+}
+
+// linkSection returns the closest "nav", "header", "footer", "main" or "aside" ancestor of the node
+// (or an element with the equivalent ARIA role), empty if the node sits in none of them
+func linkSection(n *html.Node) string {
+    for p := n.Parent; p != nil; p = p.Parent {
+        if p.Type != html.ElementNode {
+            continue
+        }
+        switch p.Data {
+        case "nav", "header", "footer", "main", "aside":
+            return p.Data
+        }
+        if section, found := sectionRoles[strings.ToLower(extractAttributes(p.Attr)["role"])]; found {
+            return section
+        }
+    }
+    return ""
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -11,7 +12,7 @@ import (
 func TestGetLinks(t *testing.T) {
     tests := []struct {
         name              string
-		category 		  string
+		category 		  LinkCategory
 		url 			  string
         responseBody      func(serverURL string) string // Function to generate response body dynamically
         expectedErr       string
@@ -116,6 +117,16 @@ func TestGetLinks(t *testing.T) {
 			expectedErr: "GetTitle failed to find any links in HTML",
 			expectedLinks: []string{},
 		},
+		// invalid category
+		{
+			name: "invalid category",
+			category: "unknown",
+			responseBody: func(serverURL string) string {
+				return fmt.Sprintf(`<html><body><a href="%s/internal1">Internal 1</a></body></html>`, serverURL)
+			},
+			expectedErr: "GetLinks failed to filter links. Invalid category: unknown",
+			expectedLinks: []string{},
+		},
 		// multiple level subdomains
 		{
 			name: "multiple level subdomains",
@@ -186,3 +197,193 @@ func TestGetLinks(t *testing.T) {
     }
 }
 
+
+func TestGetLinkDetails(t *testing.T) {
+    server := MockServer(t, `<html><body>
+        <header><a href="/" title="Home page">  Home
+            </a></header>
+        <nav><ul><li><a href="/about" target="_blank" rel="NoOpener noreferrer">About</a></li></ul></nav>
+        <div role="main">
+            <a href="http://external.com/sponsor" rel="sponsored nofollow" hreflang="en"><img src="/logo.png" alt="Sponsor"></a>
+            <a href="http://external.com/icon" aria-label="Icon link"><svg></svg></a>
+        </div>
+        <footer><a href="/contact"><span>Contact</span> <b>us</b></a></footer>
+        <a href="/plain">Plain</a>
+        </body></html>`)
+    defer server.Close()
+
+    expected := []Link{
+        {Href: "/", URL: server.URL + "/", Kind: LinkKindWeb, Text: "Home", Title: "Home page", Position: 1, Section: "header"},
+        {Href: "/about", URL: server.URL + "/about", Kind: LinkKindWeb, Text: "About", Rel: []string{"noopener", "noreferrer"}, Target: "_blank", Position: 2, Section: "nav"},
+        {Href: "http://external.com/sponsor", URL: "http://external.com/sponsor", Kind: LinkKindWeb, Text: "Sponsor", Rel: []string{"sponsored", "nofollow"}, Hreflang: "en", Position: 3, Section: "main"},
+        {Href: "http://external.com/icon", URL: "http://external.com/icon", Kind: LinkKindWeb, Text: "Icon link", Position: 4, Section: "main"},
+        {Href: "/contact", URL: server.URL + "/contact", Kind: LinkKindWeb, Text: "Contact us", Position: 5, Section: "footer"},
+        {Href: "/plain", URL: server.URL + "/plain", Kind: LinkKindWeb, Text: "Plain", Position: 6},
+    }
+
+    fetcher := NewFetcher(&FetcherProps{Timeout: 3000, CacheCap: 10})
+    links, err := fetcher.GetLinkDetails(GetLinksProps{Url: server.URL})
+    if err != nil {
+        t.Fatalf("Expected no error, got %v", err)
+    }
+    if len(links) != len(expected) {
+        t.Fatalf("Expected %d links, got %d: %+v", len(expected), len(links), links)
+    }
+    for i := range expected {
+        if !reflect.DeepEqual(links[i], expected[i]) {
+            t.Errorf("Expected link %+v, got %+v", expected[i], links[i])
+        }
+    }
+
+    // category
+    links, err = fetcher.GetLinkDetails(GetLinksProps{Url: server.URL, Category: "external"})
+    if err != nil || len(links) != 2 || links[0].Position != 3 {
+        t.Errorf("Expected 2 external links, got %+v (%v)", links, err)
+    }
+
+    // no links
+    emptyServer := MockServer(t, `<html><body></body></html>`)
+    defer emptyServer.Close()
+    if _, err := fetcher.GetLinkDetails(GetLinksProps{Url: emptyServer.URL}); err == nil || err.Error() != "GetLinkDetails failed to find any links in HTML" {
+        t.Errorf("Expected error, got %v", err)
+    }
+}
+
+func TestLinkKinds(t *testing.T) {
+    doc, err := ParseString(`<html><body>
+        <a href="/page">Page</a>
+        <a href="#section">Section</a>
+        <a href="mailto:info@example.com">Mail</a>
+        <a href="tel:+15550100">Call</a>
+        <a href="javascript:void(0)">Script</a>
+        <a href="ftp://files.example.com/file.zip">FTP</a>
+        <a href="http://external.com">External</a>
+        </body></html>`, "http://example.com/")
+    if err != nil {
+        t.Fatalf("Expected no error, got: %v", err)
+    }
+
+    tests := []struct {
+        name          string
+        props         GetLinksProps
+        expectedLinks []string
+    }{
+        {"default (links with a domain)", GetLinksProps{}, []string{"http://example.com/page", "http://example.com/#section", "ftp://files.example.com/file.zip", "http://external.com"}},
+        {"web", GetLinksProps{Kinds: []LinkKind{LinkKindWeb}}, []string{"http://example.com/page", "http://external.com"}},
+        {"email", GetLinksProps{Kinds: []LinkKind{LinkKindEmail}}, []string{"mailto:info@example.com"}},
+        {"phone", GetLinksProps{Kinds: []LinkKind{LinkKindPhone}}, []string{"tel:+15550100"}},
+        {"fragment", GetLinksProps{Kinds: []LinkKind{LinkKindFragment}}, []string{"http://example.com/#section"}},
+        {"script", GetLinksProps{Kinds: []LinkKind{LinkKindScript}}, []string{"javascript:void(0)"}},
+        {"other", GetLinksProps{Kinds: []LinkKind{LinkKindOther}}, []string{"ftp://files.example.com/file.zip"}},
+        // categories only apply to web links
+        {"external web and email", GetLinksProps{Category: LinkCategoryExternal, Kinds: []LinkKind{LinkKindWeb, LinkKindEmail}}, []string{"mailto:info@example.com", "http://external.com"}},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            links, err := doc.LinkDetails(tt.props)
+            if err != nil {
+                t.Fatalf("Expected no error, got: %v", err)
+            }
+            var urls []string
+            for _, link := range links {
+                urls = append(urls, link.URL)
+                if len(tt.props.Kinds) == 1 && link.Kind != tt.props.Kinds[0] {
+                    t.Errorf("Expected kind %s, got %s", tt.props.Kinds[0], link.Kind)
+                }
+            }
+            if !reflect.DeepEqual(urls, tt.expectedLinks) {
+                t.Errorf("Expected links %v, got %v", tt.expectedLinks, urls)
+            }
+        })
+    }
+}
+
+func TestLinksDedupeAndSort(t *testing.T) {
+    doc, err := ParseString(`<html><body>
+        <nav><a href="/b">B</a><a href="/a">A</a><a href="/c#top">C</a></nav>
+        <main><a href="/a">A</a><a href="/c">C</a><a href="/C/">C</a></main>
+        <footer><a href="/a/">A</a></footer>
+        </body></html>`, "http://example.com/")
+    if err != nil {
+        t.Fatalf("Expected no error, got: %v", err)
+    }
+
+    tests := []struct {
+        name                string
+        props               GetLinksProps
+        expectedLinks       []string
+        expectedOccurrences []int
+        expectedErr         string
+    }{
+        {
+            name:                "no dedupe",
+            props:               GetLinksProps{},
+            expectedLinks:       []string{"/b", "/a", "/c#top", "/a", "/c", "/C/", "/a/"},
+            expectedOccurrences: []int{0, 0, 0, 0, 0, 0, 0},
+        },
+        {
+            name:                "exact",
+            props:               GetLinksProps{Dedupe: LinkDedupeExact},
+            expectedLinks:       []string{"/b", "/a", "/c#top", "/c", "/C/", "/a/"},
+            expectedOccurrences: []int{1, 2, 1, 1, 1, 1},
+        },
+        {
+            name:                "normalized",
+            props:               GetLinksProps{Dedupe: LinkDedupeNormalized},
+            expectedLinks:       []string{"/b", "/a", "/c#top", "/C/"},
+            expectedOccurrences: []int{1, 3, 2, 1},
+        },
+        {
+            name:                "normalized, sorted by count",
+            props:               GetLinksProps{Dedupe: LinkDedupeNormalized, Sort: LinkSortCount},
+            expectedLinks:       []string{"/a", "/c#top", "/b", "/C/"},
+            expectedOccurrences: []int{3, 2, 1, 1},
+        },
+        {
+            name:                "normalized, sorted by url",
+            props:               GetLinksProps{Dedupe: LinkDedupeNormalized, Sort: LinkSortURL},
+            expectedLinks:       []string{"/C/", "/a", "/b", "/c#top"},
+            expectedOccurrences: []int{1, 3, 1, 2},
+        },
+        {
+            name:        "invalid dedupe",
+            props:       GetLinksProps{Dedupe: "fuzzy"},
+            expectedErr: "LinkDetails failed to deduplicate links. Invalid dedupe mode: fuzzy",
+        },
+        {
+            name:        "invalid sort",
+            props:       GetLinksProps{Sort: "random"},
+            expectedErr: "LinkDetails failed to sort links. Invalid sort mode: random",
+        },
+        {
+            name:        "sorted by count without dedupe",
+            props:       GetLinksProps{Sort: LinkSortCount},
+            expectedErr: "LinkDetails failed to sort links. Sorting by count requires a dedupe mode",
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            links, err := doc.LinkDetails(tt.props)
+            if tt.expectedErr != "" {
+                if err == nil || err.Error() != tt.expectedErr {
+                    t.Fatalf("Expected error %q, got %v", tt.expectedErr, err)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("Expected no error, got: %v", err)
+            }
+            var hrefs []string
+            var occurrences []int
+            for _, link := range links {
+                hrefs = append(hrefs, link.Href)
+                occurrences = append(occurrences, link.Occurrences)
+            }
+            if !reflect.DeepEqual(hrefs, tt.expectedLinks) || !reflect.DeepEqual(occurrences, tt.expectedOccurrences) {
+                t.Errorf("Expected links %v %v, got %v %v", tt.expectedLinks, tt.expectedOccurrences, hrefs, occurrences)
+            }
+        })
+    }
+}
@@ -0,0 +1,120 @@
+package katsuragi
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// GetManifest follows the <link rel="manifest"> tag of the given URL, fetches and parses the Web App Manifest
+func (f *Fetcher) GetManifest(url string) (*Manifest, error) {
+    htmlDoc, err := retrieveHTML(url, f)
+    if err != nil {
+        return nil, err
+    }
+    manifestURL, found := traverseAndExtractManifestURL(htmlDoc, url)
+    if !found {
+        return nil, fmt.Errorf("GetManifest failed to find manifest in HTML")
+    }
+    data, _, err := fetchResource(manifestURL, f)
+    if err != nil {
+        return nil, err
+    }
+    return ParseManifest(data, manifestURL)
+}
+
+// ParseManifest parses a Web App Manifest. Relative URLs (icons, start_url, scope) are resolved against manifestURL.
+func ParseManifest(data []byte, manifestURL string) (*Manifest, error) {
+    var raw struct {
+        Name            string `json:"name"`
+        ShortName       string `json:"short_name"`
+        Description     string `json:"description"`
+        StartURL        string `json:"start_url"`
+        Scope           string `json:"scope"`
+        Display         string `json:"display"`
+        ThemeColor      string `json:"theme_color"`
+        BackgroundColor string `json:"background_color"`
+        Icons           []struct {
+            Src     string `json:"src"`
+            Sizes   string `json:"sizes"`
+            Type    string `json:"type"`
+            Purpose string `json:"purpose"`
+        } `json:"icons"`
+    }
+    if err := json.Unmarshal(data, &raw); err != nil {
+        return nil, fmt.Errorf("ParseManifest failed to parse manifest: %v", err)
+    }
+
+    manifest := &Manifest{
+        URL:             manifestURL,
+        Name:            raw.Name,
+        ShortName:       raw.ShortName,
+        Description:     raw.Description,
+        Display:         raw.Display,
+        ThemeColor:      raw.ThemeColor,
+        BackgroundColor: raw.BackgroundColor,
+    }
+    if raw.StartURL != "" {
+        manifest.StartURL = ensureAbsoluteURL(raw.StartURL, manifestURL)
+    }
+    if raw.Scope != "" {
+        manifest.Scope = ensureAbsoluteURL(raw.Scope, manifestURL)
+    }
+    for _, rawIcon := range raw.Icons {
+        if rawIcon.Src == "" {
+            continue
+        }
+        sizes, anySize := parseIconSizes(rawIcon.Sizes)
+        // "any" is the default purpose of a manifest icon
+        purpose := strings.Fields(strings.ToLower(rawIcon.Purpose))
+        if len(purpose) == 0 {
+            purpose = []string{"any"}
+        }
+        manifest.Icons = append(manifest.Icons, Icon{
+            URL:     ensureAbsoluteURL(rawIcon.Src, manifestURL),
+            Rel:     "manifest",
+            Sizes:   sizes,
+            AnySize: anySize,
+            Type:    rawIcon.Type,
+            Purpose: purpose,
+            Source:  IconSourceManifest,
+        })
+    }
+    return manifest, nil
+}
+
+// getManifestIcons returns the icons of the manifest linked in the document.
+// Errors are ignored since the manifest is only an additional source of icons.
+func getManifestIcons(htmlDoc *html.Node, url string, f *Fetcher) []Icon {
+    manifestURL, found := traverseAndExtractManifestURL(htmlDoc, url)
+    if !found {
+        return nil
+    }
+    data, _, err := fetchResource(manifestURL, f)
+    if err != nil {
+        return nil
+    }
+    manifest, err := ParseManifest(data, manifestURL)
+    if err != nil {
+        return nil
+    }
+    return manifest.Icons
+}
+
+// traverseAndExtractManifestURL traverses the HTML node tree and extracts the absolute URL of the manifest
+func traverseAndExtractManifestURL(n *html.Node, url string) (string, bool) {
+    if n.Type == html.ElementNode && n.Data == "link" {
+        attrMap := extractAttributes(n.Attr)
+        if strings.EqualFold(attrMap["rel"], "manifest") && attrMap["href"] != "" {
+            return ensureAbsoluteURL(attrMap["href"], url), true
+        }
+    }
+    for c := n.FirstChild; c != nil; c = c.NextSibling {
+        if manifestURL, found := traverseAndExtractManifestURL(c, url); found {
+            return manifestURL, true
+        }
+    }
+    return "", false
+}
@@ -0,0 +1,136 @@
+package katsuragi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testManifest = `{
+    "name": "Example App",
+    "short_name": "Example",
+    "start_url": "/?source=pwa",
+    "display": "standalone",
+    "theme_color": "#ffffff",
+    "background_color": "#000000",
+    "icons": [
+        {"src": "/android-chrome-192x192.png", "sizes": "192x192", "type": "image/png"},
+        {"src": "icons/maskable-512.png", "sizes": "512x512", "type": "image/png", "purpose": "maskable any"},
+        {"src": ""}
+    ]
+}`
+
+// manifestServer serves an HTML page linking to a manifest at /assets/site.webmanifest
+func manifestServer(t *testing.T, htmlHead string, manifestStatus int, manifest string) *httptest.Server {
+    return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        switch r.URL.Path {
+        case "/":
+            w.Header().Set("Content-Type", "text/html")
+            w.Write([]byte("<html><head>" + htmlHead + "</head><body></body></html>"))
+        case "/assets/site.webmanifest":
+            w.Header().Set("Content-Type", "application/manifest+json")
+            w.WriteHeader(manifestStatus)
+            w.Write([]byte(manifest))
+        default:
+            w.WriteHeader(http.StatusNotFound)
+        }
+    }))
+}
+
+func TestGetManifest(t *testing.T) {
+    tests := []struct {
+        name           string
+        htmlHead       string
+        manifestStatus int
+        manifest       string
+        expectedErr    string
+    }{
+        {
+            name:           "Valid Manifest",
+            htmlHead:       `<link rel="manifest" href="/assets/site.webmanifest">`,
+            manifestStatus: http.StatusOK,
+            manifest:       testManifest,
+        },
+        {
+            name:        "No Manifest Link",
+            htmlHead:    `<link rel="icon" href="/favicon.ico">`,
+            expectedErr: "GetManifest failed to find manifest in HTML",
+        },
+        {
+            name:           "Manifest Not Found",
+            htmlHead:       `<link rel="manifest" href="/assets/site.webmanifest">`,
+            manifestStatus: http.StatusNotFound,
+            expectedErr:    "fetchResource failed to fetch URL. HTTP Status: 404 Not Found",
+        },
+        {
+            name:           "Invalid JSON",
+            htmlHead:       `<link rel="manifest" href="/assets/site.webmanifest">`,
+            manifestStatus: http.StatusOK,
+            manifest:       `{"name": `,
+            expectedErr:    "ParseManifest failed to parse manifest: unexpected end of JSON input",
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            server := manifestServer(t, tt.htmlHead, tt.manifestStatus, tt.manifest)
+            defer server.Close()
+            f := NewFetcher(&FetcherProps{Timeout: 3000, CacheCap: 10})
+            defer f.ClearCache()
+
+            manifest, err := f.GetManifest(server.URL)
+            if tt.expectedErr != "" {
+                if err == nil || err.Error() != tt.expectedErr {
+                    t.Fatalf("Expected error %q, got %v", tt.expectedErr, err)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("Expected no error, got: %v", err)
+            }
+
+            if manifest.Name != "Example App" || manifest.ShortName != "Example" || manifest.Display != "standalone" {
+                t.Errorf("Unexpected manifest metadata: %+v", manifest)
+            }
+            if manifest.ThemeColor != "#ffffff" || manifest.BackgroundColor != "#000000" {
+                t.Errorf("Unexpected manifest colors: %+v", manifest)
+            }
+            if manifest.StartURL != server.URL+"/?source=pwa" {
+                t.Errorf("Expected start_url %s, got %s", server.URL+"/?source=pwa", manifest.StartURL)
+            }
+            expectedIcons := []Icon{
+                {URL: server.URL + "/android-chrome-192x192.png", Rel: "manifest", Sizes: []IconSize{{192, 192}}, Type: "image/png", Purpose: []string{"any"}, Source: IconSourceManifest},
+                {URL: server.URL + "/assets/icons/maskable-512.png", Rel: "manifest", Sizes: []IconSize{{512, 512}}, Type: "image/png", Purpose: []string{"maskable", "any"}, Source: IconSourceManifest},
+            }
+            if !reflect.DeepEqual(manifest.Icons, expectedIcons) {
+                t.Errorf("Expected icons %+v, got %+v", expectedIcons, manifest.Icons)
+            }
+        })
+    }
+}
+
+// Manifest icons are merged into GetIcons and GetFavicons results
+func TestManifestIconsMerge(t *testing.T) {
+    server := manifestServer(t, `<link rel="manifest" href="/assets/site.webmanifest">`, http.StatusOK, testManifest)
+    defer server.Close()
+    f := NewFetcher(&FetcherProps{Timeout: 3000, CacheCap: 10})
+    defer f.ClearCache()
+
+    icons, err := f.GetIcons(server.URL)
+    if err != nil {
+        t.Fatalf("Expected no error, got: %v", err)
+    }
+    if len(icons) != 2 || icons[0].Source != IconSourceManifest {
+        t.Errorf("Expected 2 manifest icons, got %+v", icons)
+    }
+
+    favicons, err := f.GetFavicons(server.URL)
+    if err != nil {
+        t.Fatalf("Expected no error, got: %v", err)
+    }
+    if strings.Join(favicons, ",") != server.URL+"/android-chrome-192x192.png,"+server.URL+"/assets/icons/maskable-512.png" {
+        t.Errorf("Unexpected favicons: %v", favicons)
+    }
+}
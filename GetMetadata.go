@@ -0,0 +1,23 @@
+package katsuragi
+
+// GetMetadata fetches the metadata of the given URL in a single request: title, description, canonical URL
+// and language (see GetTitle, GetDescription, GetCanonical and GetLanguage). Missing metadata is left empty.
+func (f *Fetcher) GetMetadata(url string) (*Metadata, error) {
+    document, err := retrieveDocument(url, f)
+    if err != nil {
+        return nil, err
+    }
+    return document.Metadata(), nil
+}
+
+// Metadata extracts the metadata of the document, see GetMetadata
+func (d *Document) Metadata() *Metadata {
+    metadata := &Metadata{URL: d.url}
+    metadata.Title, _ = traverseAndExtractTitle(d.root)
+    metadata.Description, _ = traverseAndExtractDescription(d.root)
+    metadata.Canonical, _ = extractCanonical(d.root, d.responseHeader(), d.url)
+    if language, found := extractLanguage(d.root, d.responseHeader()); found {
+        metadata.Language = language
+    }
+    return metadata
+}
@@ -0,0 +1,154 @@
+package katsuragi
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// GetResources fetches the URLs of the resources referenced by the given URL: images (src and srcset),
+// picture/video/audio sources, iframes, stylesheets, preloads, scripts, form actions and CSS url() of inline styles.
+// data: URLs are skipped.
+func (f *Fetcher) GetResources(url string) ([]Resource, error) {
+    document, err := retrieveDocument(url, f)
+    if err != nil {
+        return nil, err
+    }
+    return document.Resources()
+}
+
+// Resources returns the resources referenced by the document, see GetResources
+func (d *Document) Resources() ([]Resource, error) {
+    if len(d.resources) == 0 {
+        return nil, fmt.Errorf("Resources failed to find any resources in HTML")
+    }
+    return d.resources, nil
+}
+
+// cssURLPattern matches url(...) values in CSS, with or without quotes
+var cssURLPattern = regexp.MustCompile(`url\(\s*(?:"([^"]*)"|'([^']*)'|([^)'"\s]*))\s*\)`)
+
+// extractResources traverses the HTML node tree and collects the unique (URL and kind) resources.
+// It must be called before cleanHtml, which removes scripts and styles.
+func extractResources(doc *html.Node, pageUrl string) []Resource {
+    var resources []Resource
+    seen := make(map[string]bool)
+
+    add := func(rawURL string, kind ResourceKind, n *html.Node, attr string) {
+        rawURL = strings.TrimSpace(rawURL)
+        if rawURL == "" || strings.HasPrefix(rawURL, "data:") {
+            return
+        }
+        resolvedUrl := ensureAbsoluteURL(rawURL, pageUrl)
+        key := string(kind) + " " + resolvedUrl
+        if seen[key] {
+            return
+        }
+        seen[key] = true
+        resources = append(resources, Resource{URL: resolvedUrl, Kind: kind, Tag: n.Data, Attr: attr})
+    }
+
+    var traverse func(*html.Node)
+    traverse = func(n *html.Node) {
+        if n.Type == html.ElementNode {
+            attrMap := extractAttributes(n.Attr)
+            switch n.Data {
+            case "img":
+                add(attrMap["src"], ResourceKindImage, n, "src")
+                for _, candidate := range parseSrcset(attrMap["srcset"]) {
+                    add(candidate.URL, ResourceKindImage, n, "srcset")
+                }
+            case "source":
+                // the kind of a <source> depends on its parent
+                kind := ResourceKindImage
+                if n.Parent != nil && n.Parent.Data == "video" {
+                    kind = ResourceKindVideo
+                } else if n.Parent != nil && n.Parent.Data == "audio" {
+                    kind = ResourceKindAudio
+                }
+                add(attrMap["src"], kind, n, "src")
+                for _, candidate := range parseSrcset(attrMap["srcset"]) {
+                    add(candidate.URL, kind, n, "srcset")
+                }
+            case "video":
+                add(attrMap["src"], ResourceKindVideo, n, "src")
+                add(attrMap["poster"], ResourceKindImage, n, "poster")
+            case "audio":
+                add(attrMap["src"], ResourceKindAudio, n, "src")
+            case "iframe":
+                add(attrMap["src"], ResourceKindIframe, n, "src")
+            case "script":
+                add(attrMap["src"], ResourceKindScript, n, "src")
+            case "form":
+                add(attrMap["action"], ResourceKindForm, n, "action")
+            case "link":
+                rel := strings.Fields(strings.ToLower(attrMap["rel"]))
+                if contains(rel, "stylesheet") {
+                    add(attrMap["href"], ResourceKindStylesheet, n, "href")
+                } else if contains(rel, "preload") || contains(rel, "modulepreload") {
+                    add(attrMap["href"], ResourceKindPreload, n, "href")
+                }
+            case "style":
+                for c := n.FirstChild; c != nil; c = c.NextSibling {
+                    if c.Type == html.TextNode {
+                        for _, cssURL := range extractCSSURLs(c.Data) {
+                            add(cssURL, ResourceKindCSS, n, "")
+                        }
+                    }
+                }
+            }
+            if style, found := attrMap["style"]; found {
+                for _, cssURL := range extractCSSURLs(style) {
+                    add(cssURL, ResourceKindCSS, n, "style")
+                }
+            }
+        }
+        for c := n.FirstChild; c != nil; c = c.NextSibling {
+            traverse(c)
+        }
+    }
+    traverse(doc)
+
+    return resources
+}
+
+// extractCSSURLs returns the values of the url() functions of a CSS snippet
+func extractCSSURLs(css string) []string {
+    var urls []string
+    for _, match := range cssURLPattern.FindAllStringSubmatch(css, -1) {
+        urls = append(urls, match[1]+match[2]+match[3])
+    }
+    return urls
+}
+
+// parseSrcset parses the candidates of a srcset attribute ("image-1x.png 1x, image-2x.png 2x").
+// URLs may contain commas, candidates are separated by a comma followed by whitespace or by a descriptor.
+func parseSrcset(srcset string) []SrcsetCandidate {
+    var candidates []SrcsetCandidate
+    rest := strings.TrimSpace(srcset)
+    for rest != "" {
+        // the URL ends at the first whitespace
+        end := strings.IndexAny(rest, " \t\n\r\f")
+        if end == -1 {
+            end = len(rest)
+        }
+        candidateURL := rest[:end]
+        rest = strings.TrimLeft(rest[end:], " \t\n\r\f")
+
+        descriptor := ""
+        if strings.HasSuffix(candidateURL, ",") {
+            // no descriptor
+            candidateURL = strings.TrimRight(candidateURL, ",")
+        } else {
+            descriptor, rest, _ = strings.Cut(rest, ",")
+            descriptor = strings.TrimSpace(descriptor)
+        }
+        rest = strings.TrimLeft(rest, " \t\n\r\f,")
+        if candidateURL != "" {
+            candidates = append(candidates, SrcsetCandidate{URL: candidateURL, Descriptor: descriptor})
+        }
+    }
+    return candidates
+}
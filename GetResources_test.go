@@ -0,0 +1,116 @@
+package katsuragi
+
+import (
+	"reflect"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestGetResources(t *testing.T) {
+    server := MockServer(t, `<html><head>
+        <link rel="stylesheet" href="/css/main.css">
+        <link rel="preload" href="/fonts/font.woff2" as="font">
+        <link rel="icon" href="/favicon.ico">
+        <script src="/js/app.js"></script>
+        <script>console.log("inline")</script>
+        <style>body { background: url("/img/bg.png"); } .logo { background: url(data:image/png;base64,AAAA) }</style>
+        </head><body>
+        <img src="/img/logo.png" srcset="/img/logo-2x.png 2x, /img/logo,3x.png 3x">
+        <picture><source srcset="/img/hero.webp" type="image/webp"><img src="/img/hero.jpg"></picture>
+        <video src="/media/intro.mp4" poster="/img/poster.jpg"><source src="/media/intro.webm"></video>
+        <audio><source src="/media/sound.ogg"></audio>
+        <iframe src="https://www.youtube.com/embed/id"></iframe>
+        <form action="/search"></form>
+        <div style="background-image: url('/img/banner.png')"></div>
+        <img src="/img/logo.png">
+        </body></html>`)
+    defer server.Close()
+    f := NewFetcher(&FetcherProps{Timeout: 3000, CacheCap: 10})
+    defer f.ClearCache()
+
+    resources, err := f.GetResources(server.URL)
+    if err != nil {
+        t.Fatalf("Expected no error, got: %v", err)
+    }
+
+    expected := []Resource{
+        {URL: "/css/main.css", Kind: ResourceKindStylesheet, Tag: "link", Attr: "href"},
+        {URL: "/fonts/font.woff2", Kind: ResourceKindPreload, Tag: "link", Attr: "href"},
+        {URL: "/js/app.js", Kind: ResourceKindScript, Tag: "script", Attr: "src"},
+        {URL: "/img/bg.png", Kind: ResourceKindCSS, Tag: "style", Attr: ""},
+        {URL: "/img/logo.png", Kind: ResourceKindImage, Tag: "img", Attr: "src"},
+        {URL: "/img/logo-2x.png", Kind: ResourceKindImage, Tag: "img", Attr: "srcset"},
+        {URL: "/img/logo,3x.png", Kind: ResourceKindImage, Tag: "img", Attr: "srcset"},
+        {URL: "/img/hero.webp", Kind: ResourceKindImage, Tag: "source", Attr: "srcset"},
+        {URL: "/img/hero.jpg", Kind: ResourceKindImage, Tag: "img", Attr: "src"},
+        {URL: "/media/intro.mp4", Kind: ResourceKindVideo, Tag: "video", Attr: "src"},
+        {URL: "/img/poster.jpg", Kind: ResourceKindImage, Tag: "video", Attr: "poster"},
+        {URL: "/media/intro.webm", Kind: ResourceKindVideo, Tag: "source", Attr: "src"},
+        {URL: "/media/sound.ogg", Kind: ResourceKindAudio, Tag: "source", Attr: "src"},
+        {URL: "https://www.youtube.com/embed/id", Kind: ResourceKindIframe, Tag: "iframe", Attr: "src"},
+        {URL: "/search", Kind: ResourceKindForm, Tag: "form", Attr: "action"},
+        {URL: "/img/banner.png", Kind: ResourceKindCSS, Tag: "div", Attr: "style"},
+    }
+    for i := range expected {
+        expected[i].URL = ensureAbsoluteURL(expected[i].URL, server.URL)
+    }
+    if len(resources) != len(expected) {
+        t.Fatalf("Expected %d resources, got %d: %+v", len(expected), len(resources), resources)
+    }
+    for i := range expected {
+        if resources[i] != expected[i] {
+            t.Errorf("Expected resource %+v, got %+v", expected[i], resources[i])
+        }
+    }
+
+    // scripts and styles are still removed from the cached document
+    doc, _, _ := f.GetFromCache(server.URL)
+    if doc == nil {
+        t.Fatalf("Expected cached document")
+    }
+    var hasScript func(*html.Node) bool
+    hasScript = func(n *html.Node) bool {
+        if n.Type == html.ElementNode && (n.Data == "script" || n.Data == "style") {
+            return true
+        }
+        for c := n.FirstChild; c != nil; c = c.NextSibling {
+            if hasScript(c) {
+                return true
+            }
+        }
+        return false
+    }
+    if hasScript(doc) {
+        t.Errorf("Expected scripts and styles to be removed from the cached document")
+    }
+}
+
+func TestGetResources_NoResources(t *testing.T) {
+    server := MockServer(t, `<html><head></head><body><p>Text</p></body></html>`)
+    defer server.Close()
+    f := NewFetcher(nil)
+
+    if _, err := f.GetResources(server.URL); err == nil || err.Error() != "Resources failed to find any resources in HTML" {
+        t.Errorf("Expected error, got %v", err)
+    }
+}
+
+func TestParseSrcset(t *testing.T) {
+    tests := []struct {
+        srcset   string
+        expected []SrcsetCandidate
+    }{
+        {"", nil},
+        {"image.png", []SrcsetCandidate{{"image.png", ""}}},
+        {"image-1x.png 1x, image-2x.png 2x", []SrcsetCandidate{{"image-1x.png", "1x"}, {"image-2x.png", "2x"}}},
+        {"small.jpg 480w,large.jpg 1080w", []SrcsetCandidate{{"small.jpg", "480w"}, {"large.jpg", "1080w"}}},
+        {"a.png, b.png 2x", []SrcsetCandidate{{"a.png", ""}, {"b.png", "2x"}}},
+        {"/img/a,b.png 1x", []SrcsetCandidate{{"/img/a,b.png", "1x"}}},
+    }
+    for _, tt := range tests {
+        if result := parseSrcset(tt.srcset); !reflect.DeepEqual(result, tt.expected) {
+            t.Errorf("parseSrcset(%q) = %v; want %v", tt.srcset, result, tt.expected)
+        }
+    }
+}
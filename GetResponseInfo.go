@@ -0,0 +1,25 @@
+package katsuragi
+
+import "context"
+
+// GetResponseInfo fetches the given URL and returns its HTTP response info: status, final URL after redirects,
+// headers, content length, fetch duration and timestamp. The info is kept in the cache along with the document,
+// so it describes the response the other functions of the Fetcher analysed. Unlike them, HTTP error statuses
+// and non-HTML responses are not errors here, only failed requests are.
+func (f *Fetcher) GetResponseInfo(url string) (*ResponseInfo, error) {
+    _, response, err := retrieveResponse(context.Background(), url, f)
+    if response == nil {
+        return nil, err
+    }
+    return copyResponseInfo(response), nil
+}
+
+// copyResponseInfo returns a copy of the cached response info, so callers cannot modify the cached headers
+func copyResponseInfo(response *ResponseInfo) *ResponseInfo {
+    if response == nil {
+        return nil
+    }
+    info := *response
+    info.Header = response.Header.Clone()
+    return &info
+}
@@ -0,0 +1,103 @@
+package katsuragi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestGetResponseInfo(t *testing.T) {
+    requests := 0
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        requests++
+        switch r.URL.Path {
+        case "/old":
+            http.Redirect(w, r, "/page", http.StatusMovedPermanently)
+        case "/page":
+            w.Header().Set("Content-Type", "text/html; charset=UTF-8")
+            w.Header().Set("Server", "test-server")
+            w.Header().Set("Cache-Control", "max-age=60")
+            w.Write([]byte("<html><head><title>Page</title></head></html>"))
+        case "/data.json":
+            w.Header().Set("Content-Type", "application/json")
+            w.Write([]byte("{}"))
+        default:
+            w.WriteHeader(http.StatusNotFound)
+        }
+    }))
+    defer server.Close()
+
+    f := NewFetcher(nil)
+    before := time.Now()
+    info, err := f.GetResponseInfo(server.URL + "/old")
+    if err != nil {
+        t.Fatalf("GetResponseInfo() error = %v", err)
+    }
+    if info.URL != server.URL+"/old" || info.FinalURL != server.URL+"/page" || info.StatusCode != http.StatusOK || info.Status != "200 OK" || info.Proto != "HTTP/1.1" {
+        t.Errorf("unexpected response info %+v", info)
+    }
+    if info.Header.Get("Server") != "test-server" || info.Header.Get("Cache-Control") != "max-age=60" || info.ContentType != "text/html" {
+        t.Errorf("unexpected headers %+v", info)
+    }
+    if info.ContentLength != int64(len("<html><head><title>Page</title></head></html>")) || info.Duration <= 0 || info.FetchedAt.Before(before) {
+        t.Errorf("unexpected length or timing %+v", info)
+    }
+
+    // the document analysed by the other functions comes from the same response
+    info.Header.Set("Server", "modified")
+    title, _ := f.GetTitle(server.URL + "/old")
+    cached, _ := f.GetResponseInfo(server.URL + "/old")
+    if title != "Page" || requests != 2 || cached.Header.Get("Server") != "test-server" || !cached.FetchedAt.Equal(info.FetchedAt) {
+        t.Errorf("expected the cached response, got %+v after %d requests", cached, requests)
+    }
+
+    // error statuses and non-HTML responses are reported and cached
+    for _, tt := range []struct {
+        path        string
+        statusCode  int
+        contentType string
+    }{
+        {"/missing", http.StatusNotFound, ""},
+        {"/data.json", http.StatusOK, "application/json"},
+    } {
+        info, err := f.GetResponseInfo(server.URL + tt.path)
+        if err != nil || info.StatusCode != tt.statusCode || info.ContentType != tt.contentType {
+            t.Errorf("GetResponseInfo(%v) = %+v, %v", tt.path, info, err)
+        }
+        if _, err := f.GetTitle(server.URL + tt.path); err == nil {
+            t.Errorf("GetTitle(%v) expected an error", tt.path)
+        }
+    }
+    if requests != 4 {
+        t.Errorf("requests = %d, want 4", requests)
+    }
+
+    if _, err := f.GetResponseInfo("http://127.0.0.1:1/unreachable"); err == nil {
+        t.Errorf("expected an error for an unreachable URL")
+    }
+}
+
+func TestDocumentResponseInfo(t *testing.T) {
+    server := MockServer(t, "<html><head><title>Page</title></head></html>")
+    defer server.Close()
+
+    document, err := retrieveDocument(server.URL, NewFetcher(nil))
+    if err != nil {
+        t.Fatalf("retrieveDocument() error = %v", err)
+    }
+    info := document.ResponseInfo()
+    if info == nil || info.StatusCode != http.StatusOK {
+        t.Fatalf("ResponseInfo() = %+v", info)
+    }
+    // the cached headers cannot be modified
+    info.Header.Set("Content-Type", "modified")
+    if document.ResponseInfo().ContentType == "modified" || document.ResponseInfo().Header.Get("Content-Type") == "modified" {
+        t.Errorf("ResponseInfo() returned the cached headers")
+    }
+
+    parsed, _ := ParseString("<html></html>", "https://www.example.com")
+    if parsed.ResponseInfo() != nil {
+        t.Errorf("expected no response info for a parsed document")
+    }
+}
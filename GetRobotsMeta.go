@@ -0,0 +1,212 @@
+package katsuragi
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// robotsMetaBots are the crawlers which can be addressed with their own meta tag, e.g. <meta name="googlebot">
+var robotsMetaBots = map[string]bool{
+    "googlebot":       true,
+    "googlebot-news":  true,
+    "googlebot-image": true,
+    "bingbot":         true,
+    "msnbot":          true,
+    "slurp":           true,
+    "yandex":          true,
+    "baiduspider":     true,
+    "duckduckbot":     true,
+}
+
+// robotsValueDirectives are the directives taking a value, e.g. "max-snippet:50"
+var robotsValueDirectives = map[string]bool{
+    "max-snippet":       true,
+    "max-image-preview": true,
+    "max-video-preview": true,
+    "unavailable_after": true,
+}
+
+// GetRobotsMeta fetches the indexing directives of the given URL from the <meta name="robots"> tags,
+// the crawler specific tags (e.g. <meta name="googlebot">) and the X-Robots-Tag response headers.
+// Pages without any directive can be indexed and followed, so no error is returned when none is found.
+func (f *Fetcher) GetRobotsMeta(url string) (*RobotsMeta, error) {
+    document, err := retrieveDocument(url, f)
+    if err != nil {
+        return nil, err
+    }
+    return document.RobotsMeta(), nil
+}
+
+// RobotsMeta extracts the indexing directives of the document and its response headers, see GetRobotsMeta
+func (d *Document) RobotsMeta() *RobotsMeta {
+    return extractRobotsMeta(d.root, d.responseHeader())
+}
+
+// For returns the directives applying to the crawler: the directives for all crawlers combined with
+// the ones for the crawler, the most restrictive winning
+func (m *RobotsMeta) For(bot string) RobotsDirectives {
+    directives := m.Directives.Directives
+    if botDirectives, found := m.Bots[strings.ToLower(bot)]; found {
+        directives = append(append([]string{}, directives...), botDirectives.Directives...)
+    }
+    return parseRobotsDirectives(directives)
+}
+
+// extractRobotsMeta collects the raw directives of the robots meta tags and X-Robots-Tag headers by crawler
+func extractRobotsMeta(doc *html.Node, header http.Header) *RobotsMeta {
+    var generic []string
+    bots := make(map[string][]string)
+
+    var traverse func(*html.Node)
+    traverse = func(n *html.Node) {
+        if n.Type == html.ElementNode && n.Data == "meta" {
+            attrMap := extractAttributes(n.Attr)
+            name := strings.ToLower(strings.TrimSpace(attrMap["name"]))
+            if name == "robots" {
+                generic = append(generic, splitRobotsDirectives(attrMap["content"])...)
+            } else if robotsMetaBots[name] {
+                bots[name] = append(bots[name], splitRobotsDirectives(attrMap["content"])...)
+            }
+        }
+        for c := n.FirstChild; c != nil; c = c.NextSibling {
+            traverse(c)
+        }
+    }
+    traverse(doc)
+
+    for _, value := range header.Values("X-Robots-Tag") {
+        // "googlebot: noindex" addresses a crawler, "max-snippet: 20" is a directive
+        if name, rest, found := strings.Cut(value, ":"); found {
+            name = strings.ToLower(strings.TrimSpace(name))
+            if !robotsValueDirectives[name] && !strings.Contains(name, ",") {
+                bots[name] = append(bots[name], splitRobotsDirectives(rest)...)
+                continue
+            }
+        }
+        generic = append(generic, splitRobotsDirectives(value)...)
+    }
+
+    meta := &RobotsMeta{Directives: parseRobotsDirectives(generic), Bots: make(map[string]RobotsDirectives)}
+    for bot, directives := range bots {
+        meta.Bots[bot] = parseRobotsDirectives(directives)
+    }
+    return meta
+}
+
+// splitRobotsDirectives splits a comma separated list of directives, keeping the commas of unavailable_after dates
+// (e.g. "unavailable_after: Friday, 25-Jun-2010 15:00:00 PST")
+func splitRobotsDirectives(content string) []string {
+    var directives []string
+    for _, token := range strings.Split(content, ",") {
+        token = strings.TrimSpace(token)
+        if token == "" {
+            continue
+        }
+        // names are case-insensitive, values such as dates are kept as is
+        name, value, hasValue := strings.Cut(token, ":")
+        name = strings.ToLower(strings.TrimSpace(name))
+        last := len(directives) - 1
+        if last >= 0 && strings.HasPrefix(directives[last], "unavailable_after") && !isRobotsDirectiveName(name) {
+            directives[last] += ", " + token
+            continue
+        }
+        if value = strings.TrimSpace(value); hasValue && value != "" {
+            name += ": " + value
+        }
+        directives = append(directives, name)
+    }
+    return directives
+}
+
+// isRobotsDirectiveName checks if the name is a known directive
+func isRobotsDirectiveName(name string) bool {
+    switch name {
+    case "all", "none", "index", "noindex", "follow", "nofollow", "noarchive", "nocache", "nosnippet",
+        "noimageindex", "notranslate", "indexifembedded":
+        return true
+    }
+    return robotsValueDirectives[name]
+}
+
+// parseRobotsDirectives combines the raw directives, the most restrictive winning
+func parseRobotsDirectives(rawDirectives []string) RobotsDirectives {
+    directives := RobotsDirectives{MaxSnippet: -1, MaxVideoPreview: -1, Directives: rawDirectives}
+    imagePreviews := map[string]int{"none": 0, "standard": 1, "large": 2}
+
+    for _, directive := range rawDirectives {
+        name, rawValue, _ := strings.Cut(directive, ":")
+        name, rawValue = strings.TrimSpace(name), strings.TrimSpace(rawValue)
+        value := strings.ToLower(rawValue)
+        if name == "unavailable_after" {
+            // the date keeps its original case, directives without a date are ignored
+            if rawValue == "" {
+                continue
+            }
+            if unavailableAfter := parseRobotsDate(rawValue); !unavailableAfter.IsZero() {
+                if directives.UnavailableAfter.IsZero() || unavailableAfter.Before(directives.UnavailableAfter) {
+                    directives.UnavailableAfter = unavailableAfter
+                }
+            }
+            continue
+        }
+        switch name {
+        case "none":
+            directives.NoIndex = true
+            directives.NoFollow = true
+        case "noindex":
+            directives.NoIndex = true
+        case "nofollow":
+            directives.NoFollow = true
+        case "noarchive", "nocache":
+            directives.NoArchive = true
+        case "nosnippet":
+            directives.NoSnippet = true
+        case "noimageindex":
+            directives.NoImageIndex = true
+        case "notranslate":
+            directives.NoTranslate = true
+        case "max-snippet":
+            if maxSnippet, err := strconv.Atoi(value); err == nil && maxSnippet >= -1 {
+                directives.MaxSnippet = minRobotsLimit(directives.MaxSnippet, maxSnippet)
+            }
+        case "max-video-preview":
+            if maxVideoPreview, err := strconv.Atoi(value); err == nil && maxVideoPreview >= -1 {
+                directives.MaxVideoPreview = minRobotsLimit(directives.MaxVideoPreview, maxVideoPreview)
+            }
+        case "max-image-preview":
+            if rank, valid := imagePreviews[value]; valid {
+                if current, set := imagePreviews[directives.MaxImagePreview]; !set || rank < current {
+                    directives.MaxImagePreview = value
+                }
+            }
+        }
+    }
+    // max-snippet:0 is the same as nosnippet
+    if directives.MaxSnippet == 0 {
+        directives.NoSnippet = true
+    }
+    return directives
+}
+
+// minRobotsLimit returns the most restrictive of two limits, -1 meaning no limit
+func minRobotsLimit(current int, limit int) int {
+    if current == -1 || (limit != -1 && limit < current) {
+        return limit
+    }
+    return current
+}
+
+// parseRobotsDate parses the date of unavailable_after, usually RFC 822, RFC 850 or ISO 8601,
+// returning the zero time if the value is invalid
+func parseRobotsDate(value string) time.Time {
+    for _, layout := range []string{time.RFC850, "Monday, 2 January 2006 15:04:05 MST", "2-Jan-2006 15:04:05 MST"} {
+        if parsed, err := time.Parse(layout, value); err == nil {
+            return fixZoneOffset(parsed)
+        }
+    }
+    return parseFeedDate(value)
+}
@@ -0,0 +1,123 @@
+package katsuragi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseRobotsDirectives(t *testing.T) {
+    tests := []struct {
+        name     string
+        content  string
+        expected RobotsDirectives
+    }{
+        {
+            name:     "None",
+            content:  "NONE",
+            expected: RobotsDirectives{NoIndex: true, NoFollow: true, MaxSnippet: -1, MaxVideoPreview: -1, Directives: []string{"none"}},
+        },
+        {
+            name:    "Limits",
+            content: "index, follow, max-snippet:50, max-image-preview:Large, max-video-preview:-1, max-snippet: 20, noarchive",
+            expected: RobotsDirectives{NoArchive: true, MaxSnippet: 20, MaxImagePreview: "large", MaxVideoPreview: -1,
+                Directives: []string{"index", "follow", "max-snippet: 50", "max-image-preview: Large", "max-video-preview: -1", "max-snippet: 20", "noarchive"}},
+        },
+        {
+            name:    "Unavailable After",
+            content: "unavailable_after: Friday, 25-Jun-10 15:00:00 UTC, nosnippet",
+            expected: RobotsDirectives{NoSnippet: true, MaxSnippet: -1, MaxVideoPreview: -1, UnavailableAfter: time.Date(2010, 6, 25, 15, 0, 0, 0, time.UTC),
+                Directives: []string{"unavailable_after: Friday, 25-Jun-10 15:00:00 UTC", "nosnippet"}},
+        },
+        {
+            name:     "Unavailable After Without Date",
+            content:  "unavailable_after, noindex, unavailable_after:",
+            expected: RobotsDirectives{NoIndex: true, MaxSnippet: -1, MaxVideoPreview: -1, Directives: []string{"unavailable_after", "noindex", "unavailable_after"}},
+        },
+        {
+            name:     "Zero Snippet",
+            content:  "max-snippet:0, max-snippet:invalid, max-image-preview:huge",
+            expected: RobotsDirectives{NoSnippet: true, MaxSnippet: 0, MaxVideoPreview: -1, Directives: []string{"max-snippet: 0", "max-snippet: invalid", "max-image-preview: huge"}},
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            directives := parseRobotsDirectives(splitRobotsDirectives(tt.content))
+            if !reflect.DeepEqual(directives, tt.expected) {
+                t.Errorf("parseRobotsDirectives() = %+v, want %+v", directives, tt.expected)
+            }
+        })
+    }
+
+    // a directive without value must not break the page
+    doc, _ := ParseString(`<html><head><meta name="robots" content="unavailable_after"></head></html>`, "https://www.example.com")
+    if meta := doc.RobotsMeta(); !meta.Directives.UnavailableAfter.IsZero() {
+        t.Errorf("unexpected robots meta %+v", meta)
+    }
+
+    // Google's documented example, 8 hours behind UTC whatever the local zone
+    pst := time.Date(2010, 6, 25, 23, 0, 0, 0, time.UTC)
+    for _, date := range []string{"25-Jun-2010 15:00:00 PST", "Friday, 25-Jun-10 15:00:00 PST", "Fri, 25 Jun 2010 16:00:00 PDT"} {
+        if parsed := parseRobotsDate(date); !parsed.Equal(pst) {
+            t.Errorf("parseRobotsDate(%q) = %v, want %v", date, parsed, pst)
+        }
+    }
+
+    for _, date := range []string{"2025-07-01", "25 Jun 2010 15:00:00 PST", "2025-07-01T10:00:00+02:00"} {
+        if parseRobotsDate(date).IsZero() {
+            t.Errorf("parseRobotsDate(%q) failed", date)
+        }
+    }
+}
+
+func TestGetRobotsMeta(t *testing.T) {
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Header().Set("Content-Type", "text/html")
+        if r.URL.Path == "/none" {
+            w.Write([]byte(`<html><head><title>Page</title></head></html>`))
+            return
+        }
+        w.Header().Add("X-Robots-Tag", "noarchive")
+        w.Header().Add("X-Robots-Tag", "bingbot: noindex, nofollow")
+        w.Header().Add("X-Robots-Tag", "max-snippet: 100")
+        w.Write([]byte(`<html><head>
+            <meta name="robots" content="max-image-preview:standard">
+            <meta name="Googlebot" content="nosnippet, max-image-preview:none">
+            <meta name="description" content="noindex">
+            </head></html>`))
+    }))
+    defer server.Close()
+
+    meta, err := NewFetcher(nil).GetRobotsMeta(server.URL + "/page")
+    if err != nil {
+        t.Fatalf("GetRobotsMeta() error = %v", err)
+    }
+    expected := RobotsDirectives{NoArchive: true, MaxSnippet: 100, MaxImagePreview: "standard", MaxVideoPreview: -1,
+        Directives: []string{"max-image-preview: standard", "noarchive", "max-snippet: 100"}}
+    if !reflect.DeepEqual(meta.Directives, expected) {
+        t.Errorf("Directives = %+v, want %+v", meta.Directives, expected)
+    }
+    if len(meta.Bots) != 2 || !meta.Bots["bingbot"].NoIndex || !meta.Bots["googlebot"].NoSnippet {
+        t.Errorf("Bots = %+v", meta.Bots)
+    }
+
+    googlebot := meta.For("Googlebot")
+    if googlebot.NoIndex || !googlebot.NoSnippet || !googlebot.NoArchive || googlebot.MaxImagePreview != "none" || googlebot.MaxSnippet != 100 {
+        t.Errorf("For(googlebot) = %+v", googlebot)
+    }
+    bingbot := meta.For("bingbot")
+    if !bingbot.NoIndex || !bingbot.NoFollow || !bingbot.NoArchive || bingbot.MaxImagePreview != "standard" {
+        t.Errorf("For(bingbot) = %+v", bingbot)
+    }
+    if other := meta.For("otherbot"); !reflect.DeepEqual(other, meta.Directives) {
+        t.Errorf("For(otherbot) = %+v, want %+v", other, meta.Directives)
+    }
+
+    // pages without directives can be indexed and followed
+    meta, err = NewFetcher(nil).GetRobotsMeta(server.URL + "/none")
+    if err != nil || meta.Directives.NoIndex || meta.Directives.NoFollow || meta.Directives.MaxSnippet != -1 || len(meta.Bots) != 0 {
+        t.Errorf("GetRobotsMeta() = %+v, %v", meta, err)
+    }
+}
@@ -0,0 +1,195 @@
+package katsuragi
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	Url "net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+    // maxSitemapSize is the maximum uncompressed size of a sitemap allowed by the protocol
+    maxSitemapSize = 50 << 20 // 50 MB
+    // maxSitemapDepth limits the nesting of sitemap indexes
+    maxSitemapDepth = 3
+)
+
+// GetSitemap discovers the sitemaps of the site of the given URL from the "Sitemap:" lines of its robots.txt,
+// falling back to /sitemap.xml, and returns the pages they list. Sitemap indexes are followed recursively
+// and gzipped sitemaps are supported. Sitemaps which cannot be fetched or parsed are skipped.
+func (f *Fetcher) GetSitemap(url string) ([]SitemapEntry, error) {
+    parsedUrl, err := Url.Parse(url)
+    if err != nil {
+        return nil, err
+    }
+    if parsedUrl.Scheme == "" || parsedUrl.Host == "" {
+        return nil, fmt.Errorf("GetSitemap failed to parse URL: %v", url)
+    }
+    origin := parsedUrl.Scheme + "://" + parsedUrl.Host
+
+    sitemapURLs := []string{origin + "/sitemap.xml"}
+    if robots := getRobotsTxt(url, f); robots != nil && len(robots.sitemaps) > 0 {
+        sitemapURLs = robots.sitemaps
+    }
+
+    var entries []SitemapEntry
+    visited := make(map[string]bool)
+    var collect func(sitemapURL string, depth int)
+    collect = func(sitemapURL string, depth int) {
+        if visited[sitemapURL] || depth > maxSitemapDepth {
+            return
+        }
+        visited[sitemapURL] = true
+        data, _, err := fetchLimitedResource(sitemapURL, f, maxSitemapSize)
+        if err != nil {
+            return
+        }
+        sitemap, err := ParseSitemap(data, sitemapURL)
+        if err != nil {
+            return
+        }
+        entries = append(entries, sitemap.Entries...)
+        for _, child := range sitemap.Sitemaps {
+            collect(child, depth+1)
+        }
+    }
+    for _, sitemapURL := range sitemapURLs {
+        collect(sitemapURL, 0)
+    }
+
+    if len(entries) == 0 {
+        return nil, fmt.Errorf("GetSitemap failed to find any sitemap entries")
+    }
+    return entries, nil
+}
+
+// ParseSitemap parses a urlset or a sitemap index, gzipped or not. Relative URLs are resolved against sitemapURL.
+func ParseSitemap(data []byte, sitemapURL string) (*Sitemap, error) {
+    // gzip magic number, .xml.gz files are usually not served with a gzip Content-Encoding
+    if len(data) > 2 && data[0] == 0x1f && data[1] == 0x8b {
+        reader, err := gzip.NewReader(bytes.NewReader(data))
+        if err != nil {
+            return nil, fmt.Errorf("ParseSitemap failed to decompress sitemap: %v", err)
+        }
+        defer reader.Close()
+        data, err = io.ReadAll(io.LimitReader(reader, maxSitemapSize))
+        if err != nil {
+            return nil, fmt.Errorf("ParseSitemap failed to decompress sitemap: %v", err)
+        }
+    }
+
+    // namespaces are ignored, so <image:image>, <news:news> and <xhtml:link> match by local name
+    var raw struct {
+        XMLName xml.Name
+        URLs    []struct {
+            Loc        string `xml:"loc"`
+            LastMod    string `xml:"lastmod"`
+            ChangeFreq string `xml:"changefreq"`
+            Priority   string `xml:"priority"`
+            Images     []struct {
+                Loc     string `xml:"loc"`
+                Title   string `xml:"title"`
+                Caption string `xml:"caption"`
+            } `xml:"image"`
+            News *struct {
+                Publication struct {
+                    Name     string `xml:"name"`
+                    Language string `xml:"language"`
+                } `xml:"publication"`
+                PublicationDate string `xml:"publication_date"`
+                Title           string `xml:"title"`
+            } `xml:"news"`
+            Links []struct {
+                Rel      string `xml:"rel,attr"`
+                Hreflang string `xml:"hreflang,attr"`
+                Href     string `xml:"href,attr"`
+            } `xml:"link"`
+        } `xml:"url"`
+        Sitemaps []struct {
+            Loc string `xml:"loc"`
+        } `xml:"sitemap"`
+    }
+    if err := xml.Unmarshal(data, &raw); err != nil {
+        return nil, fmt.Errorf("ParseSitemap failed to parse sitemap: %v", err)
+    }
+
+    sitemap := &Sitemap{URL: sitemapURL}
+    switch raw.XMLName.Local {
+    case "urlset":
+        for _, rawURL := range raw.URLs {
+            loc := strings.TrimSpace(rawURL.Loc)
+            if loc == "" {
+                continue
+            }
+            entry := SitemapEntry{
+                Loc:        ensureAbsoluteURL(loc, sitemapURL),
+                LastMod:    parseW3CDatetime(rawURL.LastMod),
+                ChangeFreq: strings.ToLower(strings.TrimSpace(rawURL.ChangeFreq)),
+                Sitemap:    sitemapURL,
+            }
+            if priority, err := strconv.ParseFloat(strings.TrimSpace(rawURL.Priority), 64); err == nil && priority >= 0 && priority <= 1 {
+                entry.Priority = &priority
+            }
+            for _, image := range rawURL.Images {
+                if imageLoc := strings.TrimSpace(image.Loc); imageLoc != "" {
+                    entry.Images = append(entry.Images, SitemapImage{
+                        Loc:     ensureAbsoluteURL(imageLoc, sitemapURL),
+                        Title:   strings.TrimSpace(image.Title),
+                        Caption: strings.TrimSpace(image.Caption),
+                    })
+                }
+            }
+            if rawURL.News != nil {
+                entry.News = &SitemapNews{
+                    PublicationName:     strings.TrimSpace(rawURL.News.Publication.Name),
+                    PublicationLanguage: strings.TrimSpace(rawURL.News.Publication.Language),
+                    PublicationDate:     parseW3CDatetime(rawURL.News.PublicationDate),
+                    Title:               strings.TrimSpace(rawURL.News.Title),
+                }
+            }
+            for _, link := range rawURL.Links {
+                if strings.EqualFold(link.Rel, "alternate") && link.Hreflang != "" && link.Href != "" {
+                    entry.Alternates = append(entry.Alternates, SitemapAlternate{
+                        Hreflang: link.Hreflang,
+                        Href:     ensureAbsoluteURL(strings.TrimSpace(link.Href), sitemapURL),
+                    })
+                }
+            }
+            sitemap.Entries = append(sitemap.Entries, entry)
+        }
+    case "sitemapindex":
+        for _, rawSitemap := range raw.Sitemaps {
+            if loc := strings.TrimSpace(rawSitemap.Loc); loc != "" {
+                sitemap.Sitemaps = append(sitemap.Sitemaps, ensureAbsoluteURL(loc, sitemapURL))
+            }
+        }
+    default:
+        return nil, fmt.Errorf("ParseSitemap failed to parse sitemap. Unexpected root element: %v", raw.XMLName.Local)
+    }
+    return sitemap, nil
+}
+
+// w3cDatetimeLayouts are the W3C Datetime formats allowed in sitemaps, from the most to the least precise
+var w3cDatetimeLayouts = []string{
+    time.RFC3339Nano,
+    "2006-01-02T15:04Z07:00",
+    "2006-01-02",
+    "2006-01",
+    "2006",
+}
+
+// parseW3CDatetime parses a W3C Datetime, returning the zero time if the value is missing or invalid
+func parseW3CDatetime(value string) time.Time {
+    value = strings.TrimSpace(value)
+    for _, layout := range w3cDatetimeLayouts {
+        if parsed, err := time.Parse(layout, value); err == nil {
+            return parsed
+        }
+    }
+    return time.Time{}
+}
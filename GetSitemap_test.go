@@ -0,0 +1,184 @@
+package katsuragi
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testUrlset = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"
+    xmlns:image="http://www.google.com/schemas/sitemap-image/1.1"
+    xmlns:news="http://www.google.com/schemas/sitemap-news/0.9"
+    xmlns:xhtml="http://www.w3.org/1999/xhtml">
+  <url>
+    <loc>https://www.example.com/</loc>
+    <lastmod>2024-05-01</lastmod>
+    <changefreq>Daily</changefreq>
+    <priority>1.0</priority>
+    <xhtml:link rel="alternate" hreflang="de" href="https://www.example.com/de/"/>
+    <xhtml:link rel="alternate" hreflang="en" href="https://www.example.com/"/>
+  </url>
+  <url>
+    <loc>/article</loc>
+    <lastmod>2024-05-02T10:30:00+02:00</lastmod>
+    <image:image>
+      <image:loc>/images/photo.jpg</image:loc>
+      <image:title>Photo</image:title>
+    </image:image>
+    <news:news>
+      <news:publication>
+        <news:name>Example Times</news:name>
+        <news:language>en</news:language>
+      </news:publication>
+      <news:publication_date>2024-05-02T10:30:00Z</news:publication_date>
+      <news:title>Breaking news</news:title>
+    </news:news>
+  </url>
+  <url><loc> </loc></url>
+</urlset>`
+
+func gzipBytes(t *testing.T, data string) []byte {
+    t.Helper()
+    var buf bytes.Buffer
+    writer := gzip.NewWriter(&buf)
+    writer.Write([]byte(data))
+    writer.Close()
+    return buf.Bytes()
+}
+
+func TestParseSitemap(t *testing.T) {
+    sitemap, err := ParseSitemap([]byte(testUrlset), "https://www.example.com/sitemap.xml")
+    if err != nil {
+        t.Fatalf("ParseSitemap() error = %v", err)
+    }
+    if len(sitemap.Entries) != 2 || len(sitemap.Sitemaps) != 0 {
+        t.Fatalf("unexpected sitemap %+v", sitemap)
+    }
+
+    home := sitemap.Entries[0]
+    if home.Loc != "https://www.example.com/" || home.ChangeFreq != "daily" || home.Priority == nil || *home.Priority != 1 ||
+        !home.LastMod.Equal(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)) || home.Sitemap != "https://www.example.com/sitemap.xml" {
+        t.Errorf("unexpected entry %+v", home)
+    }
+    wantAlternates := []SitemapAlternate{{Hreflang: "de", Href: "https://www.example.com/de/"}, {Hreflang: "en", Href: "https://www.example.com/"}}
+    if !reflect.DeepEqual(home.Alternates, wantAlternates) {
+        t.Errorf("Alternates = %v, want %v", home.Alternates, wantAlternates)
+    }
+
+    article := sitemap.Entries[1]
+    if article.Loc != "https://www.example.com/article" || article.Priority != nil || article.LastMod.IsZero() {
+        t.Errorf("unexpected entry %+v", article)
+    }
+    if !reflect.DeepEqual(article.Images, []SitemapImage{{Loc: "https://www.example.com/images/photo.jpg", Title: "Photo"}}) {
+        t.Errorf("Images = %v", article.Images)
+    }
+    wantNews := &SitemapNews{
+        PublicationName:     "Example Times",
+        PublicationLanguage: "en",
+        PublicationDate:     time.Date(2024, 5, 2, 10, 30, 0, 0, time.UTC),
+        Title:               "Breaking news",
+    }
+    if !reflect.DeepEqual(article.News, wantNews) {
+        t.Errorf("News = %+v, want %+v", article.News, wantNews)
+    }
+
+    index, err := ParseSitemap(gzipBytes(t, `<sitemapindex><sitemap><loc>/a.xml</loc></sitemap><sitemap><loc>https://cdn.example.com/b.xml.gz</loc></sitemap></sitemapindex>`), "https://www.example.com/index.xml.gz")
+    if err != nil {
+        t.Fatalf("ParseSitemap() error = %v", err)
+    }
+    if !reflect.DeepEqual(index.Sitemaps, []string{"https://www.example.com/a.xml", "https://cdn.example.com/b.xml.gz"}) {
+        t.Errorf("Sitemaps = %v", index.Sitemaps)
+    }
+
+    for _, invalid := range []string{"not xml", "<html><body></body></html>"} {
+        if _, err := ParseSitemap([]byte(invalid), "https://www.example.com/sitemap.xml"); err == nil {
+            t.Errorf("expected an error for %q", invalid)
+        }
+    }
+}
+
+func TestGetSitemap(t *testing.T) {
+    var server *httptest.Server
+    server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        switch r.URL.Path {
+        case "/robots.txt":
+            w.Write([]byte("User-agent: *\nDisallow:\nSitemap: " + server.URL + "/index.xml\n"))
+        case "/index.xml":
+            w.Write([]byte(`<sitemapindex>
+                <sitemap><loc>/pages.xml.gz</loc></sitemap>
+                <sitemap><loc>/missing.xml</loc></sitemap>
+                <sitemap><loc>/index.xml</loc></sitemap>
+                </sitemapindex>`))
+        case "/pages.xml.gz":
+            w.Header().Set("Content-Type", "application/x-gzip")
+            w.Write(gzipBytes(t, `<urlset><url><loc>/a</loc></url><url><loc>/b</loc></url></urlset>`))
+        default:
+            w.WriteHeader(http.StatusNotFound)
+        }
+    }))
+    defer server.Close()
+
+    entries, err := NewFetcher(nil).GetSitemap(server.URL + "/some/page")
+    if err != nil {
+        t.Fatalf("GetSitemap() error = %v", err)
+    }
+    var locs []string
+    for _, entry := range entries {
+        locs = append(locs, entry.Loc)
+        if entry.Sitemap != server.URL+"/pages.xml.gz" {
+            t.Errorf("Sitemap = %v", entry.Sitemap)
+        }
+    }
+    if !reflect.DeepEqual(locs, []string{server.URL + "/a", server.URL + "/b"}) {
+        t.Errorf("GetSitemap() = %v", locs)
+    }
+
+    // without robots.txt, /sitemap.xml is used
+    fallback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path == "/sitemap.xml" {
+            w.Write([]byte(`<urlset><url><loc>/only</loc></url></urlset>`))
+            return
+        }
+        w.WriteHeader(http.StatusNotFound)
+    }))
+    defer fallback.Close()
+    entries, err = NewFetcher(nil).GetSitemap(fallback.URL)
+    if err != nil || len(entries) != 1 || entries[0].Loc != fallback.URL+"/only" {
+        t.Errorf("GetSitemap() = %v, %v", entries, err)
+    }
+
+    empty := MockServer(t, "<html></html>")
+    defer empty.Close()
+    if _, err := NewFetcher(nil).GetSitemap(empty.URL); err == nil {
+        t.Errorf("expected an error without sitemap")
+    }
+}
+
+func TestGetSitemap_LargeSitemap(t *testing.T) {
+    // larger than the limit of other resources, the entry after the padding is only found if nothing is cut off
+    sitemap := `<urlset><url><loc>/first</loc></url>` + strings.Repeat(" ", maxResourceSize) + `<url><loc>/last</loc></url></urlset>`
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path == "/sitemap.xml" {
+            w.Write([]byte(sitemap))
+            return
+        }
+        w.WriteHeader(http.StatusNotFound)
+    }))
+    defer server.Close()
+
+    entries, err := NewFetcher(nil).GetSitemap(server.URL)
+    if err != nil || len(entries) != 2 || entries[1].Loc != server.URL+"/last" {
+        t.Errorf("GetSitemap() = %v, %v", entries, err)
+    }
+
+    // larger resources are an error
+    if _, _, err := fetchLimitedResource(server.URL+"/sitemap.xml", NewFetcher(nil), 1024); err == nil {
+        t.Errorf("expected an error for a resource over the limit")
+    }
+}
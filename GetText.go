@@ -0,0 +1,107 @@
+package katsuragi
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// GetText fetches the text of the given URL visible to readers, e.g. for full-text indexing.
+// Hidden elements (the head, scripts, styles, <noscript>, <template>, the hidden attribute, aria-hidden="true",
+// display:none...) and comments are skipped. Block elements (paragraphs, headings, list items, table cells...)
+// start new lines and whitespace is collapsed within the lines.
+func (f *Fetcher) GetText(url string) (string, error) {
+    document, err := retrieveDocument(url, f)
+    if err != nil {
+        return "", err
+    }
+    text := extractVisibleText(document.root)
+    if text == "" {
+        return "", fmt.Errorf("GetText failed to find text in HTML")
+    }
+    return text, nil
+}
+
+// Text extracts the visible text of the document, see GetText
+func (d *Document) Text() (string, error) {
+    text := extractVisibleText(d.root)
+    if text == "" {
+        return "", fmt.Errorf("Text failed to find text in HTML")
+    }
+    return text, nil
+}
+
+// textBlockElements separate the lines of the visible text
+var textBlockElements = map[string]bool{
+    "address": true, "article": true, "aside": true, "blockquote": true, "br": true, "dd": true, "details": true,
+    "div": true, "dl": true, "dt": true, "fieldset": true, "figcaption": true, "figure": true, "footer": true,
+    "form": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "header": true,
+    "hr": true, "li": true, "main": true, "nav": true, "ol": true, "p": true, "pre": true, "section": true,
+    "summary": true, "table": true, "td": true, "th": true, "tr": true, "ul": true,
+}
+
+// hiddenTextElements never hold visible text
+var hiddenTextElements = map[string]bool{
+    "head": true, "script": true, "style": true, "noscript": true, "template": true, "svg": true,
+    "iframe": true, "object": true, "canvas": true,
+}
+
+// isHiddenElement checks if the element is hidden with the hidden attribute, aria-hidden or an inline style
+func isHiddenElement(n *html.Node) bool {
+    if hiddenTextElements[n.Data] {
+        return true
+    }
+    attrMap := extractAttributes(n.Attr)
+    if _, found := attrMap["hidden"]; found {
+        return true
+    }
+    if strings.EqualFold(attrMap["aria-hidden"], "true") {
+        return true
+    }
+    if n.Data == "input" && strings.EqualFold(attrMap["type"], "hidden") {
+        return true
+    }
+    style := strings.ReplaceAll(strings.ToLower(attrMap["style"]), " ", "")
+    return strings.Contains(style, "display:none") || strings.Contains(style, "visibility:hidden")
+}
+
+// extractVisibleText returns the text of the node visible to readers, one line per block element
+// with collapsed whitespace. Hidden elements (head, scripts, hidden attribute, aria-hidden, display:none...) are skipped.
+func extractVisibleText(n *html.Node) string {
+    var builder strings.Builder
+    var collect func(*html.Node)
+    collect = func(n *html.Node) {
+        switch n.Type {
+        case html.TextNode:
+            // line breaks of the source are whitespace, lines are made by block elements
+            builder.WriteString(strings.Map(func(r rune) rune {
+                if r == '\n' || r == '\r' {
+                    return ' '
+                }
+                return r
+            }, n.Data))
+            return
+        case html.ElementNode:
+            if isHiddenElement(n) {
+                return
+            }
+            if textBlockElements[n.Data] {
+                builder.WriteString("\n")
+                defer builder.WriteString("\n")
+            }
+        }
+        for c := n.FirstChild; c != nil; c = c.NextSibling {
+            collect(c)
+        }
+    }
+    collect(n)
+
+    var lines []string
+    for _, line := range strings.Split(builder.String(), "\n") {
+        if line = strings.Join(strings.Fields(line), " "); line != "" {
+            lines = append(lines, line)
+        }
+    }
+    return strings.Join(lines, "\n")
+}
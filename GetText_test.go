@@ -0,0 +1,82 @@
+package katsuragi
+
+import (
+	"testing"
+)
+
+func TestGetText(t *testing.T) {
+    tests := []struct {
+        name     string
+        html     string
+        expected string
+        err      string
+    }{
+        {
+            name: "Blocks and whitespace",
+            html: `<html><head><title>Title</title><style>p { color: red; }</style></head><body>
+                <h1>  Hello,
+                    world!</h1>
+                <p>First <b>bold</b>	and <a href="/">link</a>.<br>Second line</p>
+                <ul><li>One</li><li>Two</li></ul>
+                <table><tr><td>Cell 1</td><td>Cell 2</td></tr></table>
+                </body></html>`,
+            expected: "Hello, world!\nFirst bold and link.\nSecond line\nOne\nTwo\nCell 1\nCell 2",
+        },
+        {
+            name: "Hidden elements",
+            html: `<html><body>
+                <p>Visible</p>
+                <script>var hidden = true;</script>
+                <noscript>Enable JavaScript</noscript>
+                <template><p>Template</p></template>
+                <!-- comment -->
+                <div hidden>Hidden attribute</div>
+                <span aria-hidden="true">Icon</span>
+                <p style="display: none">Display none</p>
+                <p style="visibility:hidden">Visibility hidden</p>
+                <input type="hidden" value="token">
+                <p>Also <span aria-hidden="false">visible</span></p>
+                </body></html>`,
+            expected: "Visible\nAlso visible",
+        },
+        {
+            name: "No text",
+            html: `<html><head><title>Title</title></head><body><script>var x;</script></body></html>`,
+            err:  "GetText failed to find text in HTML",
+        },
+    }
+
+    for _, test := range tests {
+        t.Run(test.name, func(t *testing.T) {
+            server := MockServer(t, test.html)
+            defer server.Close()
+
+            text, err := NewFetcher(nil).GetText(server.URL)
+            if test.err != "" {
+                if err == nil || err.Error() != test.err {
+                    t.Errorf("Expected error %q, got %v", test.err, err)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("GetText() error = %v", err)
+            }
+            if text != test.expected {
+                t.Errorf("Expected %q, got %q", test.expected, text)
+            }
+        })
+    }
+}
+
+func TestDocumentText(t *testing.T) {
+    doc, _ := ParseString(`<html><body><div>Some <em>text</em></div><div>More text</div></body></html>`, "https://www.example.com")
+    text, err := doc.Text()
+    if err != nil || text != "Some text\nMore text" {
+        t.Errorf("Text() = %q, %v", text, err)
+    }
+
+    empty, _ := ParseString(`<html><body></body></html>`, "https://www.example.com")
+    if _, err := empty.Text(); err == nil || err.Error() != "Text failed to find text in HTML" {
+        t.Errorf("Expected error, got %v", err)
+    }
+}
@@ -0,0 +1,100 @@
+package katsuragi
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// browserConfigTiles maps the tile elements of browserconfig.xml to their size
+var browserConfigTiles = map[string]IconSize{
+    "square70x70logo":   {Width: 70, Height: 70},
+    "square150x150logo": {Width: 150, Height: 150},
+    "wide310x150logo":   {Width: 310, Height: 150},
+    "square310x310logo": {Width: 310, Height: 310},
+    "tileimage":         {Width: 144, Height: 144},
+}
+
+// ParseBrowserConfig parses a browserconfig.xml file (Microsoft tiles) and returns its tile images as icons.
+// Relative URLs are resolved against configURL.
+func ParseBrowserConfig(data []byte, configURL string) ([]Icon, error) {
+    var raw struct {
+        Tile struct {
+            Elements []struct {
+                XMLName xml.Name
+                Src     string `xml:"src,attr"`
+                Value   string `xml:",chardata"`
+            } `xml:",any"`
+        } `xml:"msapplication>tile"`
+    }
+    decoder := xml.NewDecoder(bytes.NewReader(data))
+    decoder.Strict = false
+    if err := decoder.Decode(&raw); err != nil {
+        return nil, fmt.Errorf("ParseBrowserConfig failed to parse browserconfig: %v", err)
+    }
+
+    var icons []Icon
+    tileColor := ""
+    for _, element := range raw.Tile.Elements {
+        name := strings.ToLower(element.XMLName.Local)
+        if name == "tilecolor" {
+            tileColor = strings.TrimSpace(element.Value)
+            continue
+        }
+        size, found := browserConfigTiles[name]
+        if !found || element.Src == "" {
+            continue
+        }
+        icons = append(icons, Icon{
+            URL:    ensureAbsoluteURL(element.Src, configURL),
+            Sizes:  []IconSize{size},
+            Source: IconSourceBrowserConfig,
+        })
+    }
+    for i := range icons {
+        icons[i].Color = tileColor
+    }
+    return icons, nil
+}
+
+// getBrowserConfigIcons returns the tiles of the browserconfig.xml declared with <meta name="msapplication-config">.
+// Errors are ignored since browserconfig.xml is only an additional source of icons.
+func getBrowserConfigIcons(htmlDoc *html.Node, url string, f *Fetcher) []Icon {
+    configURL, found := traverseAndExtractBrowserConfigURL(htmlDoc, url)
+    if !found {
+        return nil
+    }
+    data, _, err := fetchResource(configURL, f)
+    if err != nil {
+        return nil
+    }
+    icons, err := ParseBrowserConfig(data, configURL)
+    if err != nil {
+        return nil
+    }
+    return icons
+}
+
+// traverseAndExtractBrowserConfigURL traverses the HTML node tree and extracts the absolute URL of browserconfig.xml.
+// content="none" disables browserconfig.xml.
+func traverseAndExtractBrowserConfigURL(n *html.Node, url string) (string, bool) {
+    if n.Type == html.ElementNode && n.Data == "meta" {
+        attrMap := extractAttributes(n.Attr)
+        if strings.EqualFold(attrMap["name"], "msapplication-config") {
+            content := strings.TrimSpace(attrMap["content"])
+            if content == "" || strings.EqualFold(content, "none") {
+                return "", false
+            }
+            return ensureAbsoluteURL(content, url), true
+        }
+    }
+    for c := n.FirstChild; c != nil; c = c.NextSibling {
+        if configURL, found := traverseAndExtractBrowserConfigURL(c, url); found {
+            return configURL, true
+        }
+    }
+    return "", false
+}
@@ -0,0 +1,82 @@
+package katsuragi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+const testBrowserConfig = `<?xml version="1.0" encoding="utf-8"?>
+<browserconfig>
+    <msapplication>
+        <tile>
+            <square70x70logo src="/mstile-70x70.png"/>
+            <square150x150logo src="mstile-150x150.png"/>
+            <wide310x150logo src="/mstile-310x150.png"/>
+            <square310x310logo src=""/>
+            <TileColor>#2b5797</TileColor>
+        </tile>
+    </msapplication>
+</browserconfig>`
+
+func TestParseBrowserConfig(t *testing.T) {
+    icons, err := ParseBrowserConfig([]byte(testBrowserConfig), "http://example.com/assets/browserconfig.xml")
+    if err != nil {
+        t.Fatalf("Expected no error, got: %v", err)
+    }
+    expected := []Icon{
+        {URL: "http://example.com/mstile-70x70.png", Sizes: []IconSize{{70, 70}}, Color: "#2b5797", Source: IconSourceBrowserConfig},
+        {URL: "http://example.com/assets/mstile-150x150.png", Sizes: []IconSize{{150, 150}}, Color: "#2b5797", Source: IconSourceBrowserConfig},
+        {URL: "http://example.com/mstile-310x150.png", Sizes: []IconSize{{310, 150}}, Color: "#2b5797", Source: IconSourceBrowserConfig},
+    }
+    if !reflect.DeepEqual(icons, expected) {
+        t.Errorf("Expected icons %+v, got %+v", expected, icons)
+    }
+
+    if _, err := ParseBrowserConfig([]byte("not xml"), "http://example.com"); err == nil {
+        t.Errorf("Expected error for invalid browserconfig, got none")
+    }
+}
+
+func TestGetIcons_BrowserConfig(t *testing.T) {
+    tests := []struct {
+        name          string
+        configMeta    string
+        expectedIcons int
+    }{
+        {"Declared", `<meta name="msapplication-config" content="/browserconfig.xml">`, 3},
+        {"Disabled", `<meta name="msapplication-config" content="none">`, 0},
+        {"Not Declared", ``, 0},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+                switch r.URL.Path {
+                case "/":
+                    w.Header().Set("Content-Type", "text/html")
+                    w.Write([]byte(`<html><head>` + tt.configMeta + `</head><body></body></html>`))
+                case "/browserconfig.xml":
+                    w.Header().Set("Content-Type", "application/xml")
+                    w.Write([]byte(testBrowserConfig))
+                default:
+                    w.WriteHeader(http.StatusNotFound)
+                }
+            }))
+            defer server.Close()
+            f := NewFetcher(&FetcherProps{Timeout: 3000, CacheCap: 10})
+            defer f.ClearCache()
+
+            icons, _ := f.GetIcons(server.URL)
+            if len(icons) != tt.expectedIcons {
+                t.Fatalf("Expected %d icons, got %d: %+v", tt.expectedIcons, len(icons), icons)
+            }
+            for _, icon := range icons {
+                if icon.Source != IconSourceBrowserConfig {
+                    t.Errorf("Expected browserconfig icon, got %+v", icon)
+                }
+            }
+        })
+    }
+}
@@ -7,21 +7,47 @@ import (
 )
 
 func (f *Fetcher) GetFromCache(url string) (*html.Node, bool, error) {
-    f.mu.RLock()
-    defer f.mu.RUnlock()
+    document, found, err := f.getDocumentFromCache(url)
+    if document == nil {
+        return nil, found, err
+    }
+    return document.root, found, err
+}
+
+// getDocumentFromCache returns the cached document along with the data captured while fetching it
+func (f *Fetcher) getDocumentFromCache(url string) (*Document, bool, error) {
+    entry, found := f.getCacheEntry(url)
+    if !found {
+        return nil, false, nil
+    }
+    if entry.isError {
+        return nil, true, entry.err
+    }
+    return entry.document, true, nil
+}
+
+// getCacheEntry returns a copy of the cache entry of the URL
+func (f *Fetcher) getCacheEntry(url string) (cacheEntry, bool) {
+    // MoveToFront modifies the LRU list, so a read lock is not enough
+    f.mu.Lock()
+    defer f.mu.Unlock()
 
     if elem, ok := f.cache[url]; ok {
         f.lruList.MoveToFront(elem)
-        entry := elem.Value.(*cacheEntry)
-        if entry.isError {
-            return nil, true, entry.err
-        }
-        return entry.response, true, nil
+        return *elem.Value.(*cacheEntry), true
     }
-    return nil, false, nil
+    return cacheEntry{}, false
 }
 
 func (f *Fetcher) addToCache(url string, response *html.Node, err error) {
+    var document *Document
+    if response != nil {
+        document = &Document{root: response, url: url}
+    }
+    f.addDocumentToCache(url, document, nil, err)
+}
+
+func (f *Fetcher) addDocumentToCache(url string, document *Document, response *ResponseInfo, err error) {
     f.mu.Lock()
     defer f.mu.Unlock()
 
@@ -30,6 +56,7 @@ func (f *Fetcher) addToCache(url string, response *html.Node, err error) {
     if elem, ok := f.cache[url]; ok {
         f.lruList.MoveToFront(elem)
         entry := elem.Value.(*cacheEntry)
+        entry.document = document
         entry.response = response
         entry.isError = isError
         entry.err = err
@@ -45,7 +72,7 @@ func (f *Fetcher) addToCache(url string, response *html.Node, err error) {
         }
     }
 
-    entry := &cacheEntry{url: url, response: response, isError: isError, err: err}
+    entry := &cacheEntry{url: url, document: document, response: response, isError: isError, err: err}
     elem := f.lruList.PushFront(entry)
     f.cache[url] = elem
 }
@@ -56,4 +83,4 @@ func (f *Fetcher) ClearCache() {
 
     f.cache = make(map[string]*list.Element)
     f.lruList = list.New()
-}
\ No newline at end of file
+}
@@ -0,0 +1,104 @@
+package katsuragi
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	Url "net/url"
+	"os"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// ParseDocument parses an HTML document from the given reader without any network access.
+// baseURL is used to resolve relative links and favicons, the same way the page URL is used by the Fetcher.
+func ParseDocument(r io.Reader, baseURL string) (*Document, error) {
+    if _, err := Url.Parse(baseURL); err != nil {
+        return nil, fmt.Errorf("ParseDocument failed to parse base URL: %v", err)
+    }
+    doc, err := html.Parse(r)
+    if err != nil {
+        return nil, fmt.Errorf("ParseDocument failed to parse HTML: %v", err)
+    }
+
+    // Remove script and style tags, the same as retrieveHTML does before caching
+    return newDocument(doc, baseURL), nil
+}
+
+// ParseString parses an HTML document from a string. See ParseDocument.
+func ParseString(htmlDoc string, baseURL string) (*Document, error) {
+    return ParseDocument(strings.NewReader(htmlDoc), baseURL)
+}
+
+// ParseFile parses an HTML document from a local file. See ParseDocument.
+func ParseFile(path string, baseURL string) (*Document, error) {
+    file, err := os.Open(path)
+    if err != nil {
+        return nil, fmt.Errorf("ParseFile failed to open file: %v", err)
+    }
+    defer file.Close()
+    return ParseDocument(file, baseURL)
+}
+
+// URL returns the base URL the document was parsed with
+func (d *Document) URL() string {
+    return d.url
+}
+
+// Title extracts the title of the document, see GetTitle
+func (d *Document) Title() (string, error) {
+    title, found := traverseAndExtractTitle(d.root)
+    if !found {
+        return "", fmt.Errorf("Title failed to find title in HTML")
+    }
+    return title, nil
+}
+
+// Description extracts the description of the document, see GetDescription
+func (d *Document) Description() (string, error) {
+    description, found := traverseAndExtractDescription(d.root)
+    if !found {
+        return "", fmt.Errorf("Description failed to find description in HTML")
+    }
+    return description, nil
+}
+
+// Favicons extracts the favicons declared in the document, see GetFavicons.
+// Unlike GetFavicons, the root /favicon.ico is not probed since no network requests are made.
+func (d *Document) Favicons() ([]string, error) {
+    favicons, found := traverseAndExtractFavicons(d.root, d.url)
+    if !found {
+        return nil, fmt.Errorf("Favicons failed to find any favicons")
+    }
+    return favicons, nil
+}
+
+// Links extracts the links of the document, see GetLinks.
+// Links are resolved against the document URL unless props.Url is set.
+func (d *Document) Links(props GetLinksProps) ([]string, error) {
+    if props.Url == "" {
+        props.Url = d.url
+    }
+    if err := normalizeLinksProps(&props, "Links"); err != nil {
+        return nil, err
+    }
+    links := extractLinks(d.root, props)
+    if len(links) == 0 {
+        return nil, fmt.Errorf("Links failed to find any links in HTML")
+    }
+    return links, nil
+}
+
+// ResponseInfo returns a copy of the HTTP response the document was fetched with, nil for documents parsed with ParseDocument
+func (d *Document) ResponseInfo() *ResponseInfo {
+    return copyResponseInfo(d.response)
+}
+
+// responseHeader returns the response headers of the document, nil for documents parsed with ParseDocument
+func (d *Document) responseHeader() http.Header {
+    if d.response == nil {
+        return nil
+    }
+    return d.response.Header
+}
@@ -0,0 +1,102 @@
+package katsuragi
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseDocument(t *testing.T) {
+    htmlDoc := `<html><head>
+        <title>Example Title</title>
+        <meta name="description" content="Example Description">
+        <link rel="icon" href="/favicon.png">
+        </head><body>
+        <a href="/internal1">Internal 1</a>
+        <a href="http://external.com">External</a>
+        </body></html>`
+
+    doc, err := ParseDocument(strings.NewReader(htmlDoc), "http://example.com")
+    if err != nil {
+        t.Fatalf("Expected no error, got: %v", err)
+    }
+
+    title, err := doc.Title()
+    if err != nil || title != "Example Title" {
+        t.Errorf("Expected title `Example Title`, got %q (%v)", title, err)
+    }
+    description, err := doc.Description()
+    if err != nil || description != "Example Description" {
+        t.Errorf("Expected description `Example Description`, got %q (%v)", description, err)
+    }
+    favicons, err := doc.Favicons()
+    if err != nil || len(favicons) != 1 || favicons[0] != "http://example.com/favicon.png" {
+        t.Errorf("Expected favicon `http://example.com/favicon.png`, got %v (%v)", favicons, err)
+    }
+
+    tests := []struct {
+        category      LinkCategory
+        expectedLinks []string
+    }{
+        {"", []string{"http://example.com/internal1", "http://external.com"}},
+        {"internal", []string{"http://example.com/internal1"}},
+        {"external", []string{"http://external.com"}},
+    }
+    for _, tt := range tests {
+        links, err := doc.Links(GetLinksProps{Category: tt.category})
+        if err != nil {
+            t.Fatalf("Expected no error, got: %v", err)
+        }
+        if strings.Join(links, ",") != strings.Join(tt.expectedLinks, ",") {
+            t.Errorf("Category %q: expected links %v, got %v", tt.category, tt.expectedLinks, links)
+        }
+    }
+}
+
+func TestParseDocument_Empty(t *testing.T) {
+    doc, err := ParseString("", "http://example.com")
+    if err != nil {
+        t.Fatalf("Expected no error, got: %v", err)
+    }
+    if _, err := doc.Title(); err == nil || err.Error() != "Title failed to find title in HTML" {
+        t.Errorf("Expected title error, got %v", err)
+    }
+    if _, err := doc.Description(); err == nil || err.Error() != "Description failed to find description in HTML" {
+        t.Errorf("Expected description error, got %v", err)
+    }
+    if _, err := doc.Favicons(); err == nil || err.Error() != "Favicons failed to find any favicons" {
+        t.Errorf("Expected favicons error, got %v", err)
+    }
+    if _, err := doc.Links(GetLinksProps{}); err == nil || err.Error() != "Links failed to find any links in HTML" {
+        t.Errorf("Expected links error, got %v", err)
+    }
+}
+
+func TestParseDocument_BadBaseURL(t *testing.T) {
+    _, err := ParseString("<html></html>", "http://%gh&%$")
+    if err == nil {
+        t.Fatalf("Expected error, got none")
+    }
+}
+
+func TestParseFile(t *testing.T) {
+    doc, err := ParseFile("testdata/template.html", "http://example.com")
+    if err != nil {
+        t.Fatalf("Expected no error, got: %v", err)
+    }
+    if doc.URL() != "http://example.com" {
+        t.Errorf("Expected URL `http://example.com`, got %s", doc.URL())
+    }
+    title, err := doc.Title()
+    if err != nil || title != "[title-tag] Test Server Title" {
+        t.Errorf("Expected title from template, got %q (%v)", title, err)
+    }
+    favicons, err := doc.Favicons()
+    // includes the mask-icon
+    if err != nil || len(favicons) != 4 {
+        t.Errorf("Expected 4 favicons, got %v (%v)", favicons, err)
+    }
+
+    if _, err := ParseFile("testdata/missing.html", "http://example.com"); err == nil {
+        t.Errorf("Expected error for missing file, got none")
+    }
+}
@@ -3,3 +3,5 @@ module github.com/devnyxie/katsuragi
 go 1.22.0
 
 require golang.org/x/net v0.27.0
+
+require golang.org/x/text v0.16.0 // indirect
@@ -0,0 +1,105 @@
+package katsuragi
+
+import (
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// matchLinkFilters checks if the link matches all filters
+func matchLinkFilters(link Link, filters []LinkFilter) bool {
+    for _, filter := range filters {
+        if filter != nil && !filter(link) {
+            return false
+        }
+    }
+    return true
+}
+
+// FilterSchemes selects links with one of the given schemes (e.g. "https")
+func FilterSchemes(schemes ...string) LinkFilter {
+    return func(link Link) bool {
+        parsedUrl, err := url.Parse(link.URL)
+        if err != nil {
+            return false
+        }
+        for _, scheme := range schemes {
+            if strings.EqualFold(parsedUrl.Scheme, scheme) {
+                return true
+            }
+        }
+        return false
+    }
+}
+
+// FilterInclude selects links whose resolved URL matches the regular expression
+func FilterInclude(pattern *regexp.Regexp) LinkFilter {
+    return func(link Link) bool {
+        return pattern.MatchString(link.URL)
+    }
+}
+
+// FilterExclude selects links whose resolved URL does not match the regular expression
+func FilterExclude(pattern *regexp.Regexp) LinkFilter {
+    return Not(FilterInclude(pattern))
+}
+
+// FilterExtensions selects links whose path ends with one of the given file extensions (e.g. ".pdf" or "pdf").
+// The comparison is case-insensitive.
+func FilterExtensions(extensions ...string) LinkFilter {
+    return func(link Link) bool {
+        parsedUrl, err := url.Parse(link.URL)
+        if err != nil {
+            return false
+        }
+        ext := strings.ToLower(path.Ext(parsedUrl.Path))
+        if ext == "" {
+            return false
+        }
+        for _, extension := range extensions {
+            if ext == "."+strings.TrimPrefix(strings.ToLower(extension), ".") {
+                return true
+            }
+        }
+        return false
+    }
+}
+
+// FilterRel selects links having at least one of the given rel tokens (e.g. "nofollow", "sponsored", "ugc")
+func FilterRel(rel ...string) LinkFilter {
+    return func(link Link) bool {
+        for _, token := range rel {
+            if contains(link.Rel, strings.ToLower(token)) {
+                return true
+            }
+        }
+        return false
+    }
+}
+
+// Not inverts a filter
+func Not(filter LinkFilter) LinkFilter {
+    return func(link Link) bool {
+        return !filter(link)
+    }
+}
+
+// AnyOf selects links matching at least one of the filters
+func AnyOf(filters ...LinkFilter) LinkFilter {
+    return func(link Link) bool {
+        for _, filter := range filters {
+            if filter(link) {
+                return true
+            }
+        }
+        return false
+    }
+}
+
+// AllOf selects links matching all filters
+func AllOf(filters ...LinkFilter) LinkFilter {
+    return func(link Link) bool {
+        return matchLinkFilters(link, filters)
+    }
+}
@@ -0,0 +1,81 @@
+package katsuragi
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+const testLinksPage = `<html><body>
+    <a href="/docs/guide.pdf">Guide</a>
+    <a href="/about">About</a>
+    <a href="http://blog.example.com/post">Blog</a>
+    <a href="http://a.blog.example.com/post">Nested blog</a>
+    <a href="https://example.com/secure">Secure</a>
+    <a href="http://external.com/sponsor" rel="sponsored">Sponsor</a>
+    <a href="http://external.com/ugc.PDF" rel="ugc nofollow">Comment</a>
+    </body></html>`
+
+func TestLinkCategories(t *testing.T) {
+    doc, err := ParseString(testLinksPage, "http://blog.example.com/")
+    if err != nil {
+        t.Fatalf("Expected no error, got: %v", err)
+    }
+
+    tests := []struct {
+        category      LinkCategory
+        expectedLinks []string
+        expectedErr   string
+    }{
+        {LinkCategorySameHost, []string{"http://blog.example.com/docs/guide.pdf", "http://blog.example.com/about", "http://blog.example.com/post"}, ""},
+        {LinkCategorySameSubdomain, []string{"http://blog.example.com/docs/guide.pdf", "http://blog.example.com/about", "http://blog.example.com/post", "http://a.blog.example.com/post"}, ""},
+        {LinkCategorySameDomain, []string{"http://blog.example.com/docs/guide.pdf", "http://blog.example.com/about", "http://blog.example.com/post", "http://a.blog.example.com/post", "https://example.com/secure"}, ""},
+        {LinkCategoryExternal, []string{"http://external.com/sponsor", "http://external.com/ugc.PDF"}, ""},
+        {"unknown", nil, "Links failed to filter links. Invalid category: unknown"},
+    }
+
+    for _, tt := range tests {
+        links, err := doc.Links(GetLinksProps{Category: tt.category})
+        if tt.expectedErr != "" {
+            if err == nil || err.Error() != tt.expectedErr {
+                t.Errorf("Category %q: expected error %q, got %v", tt.category, tt.expectedErr, err)
+            }
+            continue
+        }
+        if strings.Join(links, ",") != strings.Join(tt.expectedLinks, ",") {
+            t.Errorf("Category %q: expected links %v, got %v (%v)", tt.category, tt.expectedLinks, links, err)
+        }
+    }
+}
+
+func TestLinkFilters(t *testing.T) {
+    doc, err := ParseString(testLinksPage, "http://blog.example.com/")
+    if err != nil {
+        t.Fatalf("Expected no error, got: %v", err)
+    }
+
+    tests := []struct {
+        name          string
+        filters       []LinkFilter
+        expectedLinks []string
+    }{
+        {"Schemes", []LinkFilter{FilterSchemes("HTTPS")}, []string{"https://example.com/secure"}},
+        {"Include", []LinkFilter{FilterInclude(regexp.MustCompile(`/post$`))}, []string{"http://blog.example.com/post", "http://a.blog.example.com/post"}},
+        {"Exclude", []LinkFilter{FilterExclude(regexp.MustCompile(`example\.com`))}, []string{"http://external.com/sponsor", "http://external.com/ugc.PDF"}},
+        {"Extensions", []LinkFilter{FilterExtensions("pdf")}, []string{"http://blog.example.com/docs/guide.pdf", "http://external.com/ugc.PDF"}},
+        {"Rel", []LinkFilter{FilterRel("nofollow", "sponsored")}, []string{"http://external.com/sponsor", "http://external.com/ugc.PDF"}},
+        {"Not Rel", []LinkFilter{Not(FilterRel("ugc")), FilterExtensions(".pdf")}, []string{"http://blog.example.com/docs/guide.pdf"}},
+        {"AnyOf", []LinkFilter{AnyOf(FilterSchemes("https"), FilterRel("sponsored"))}, []string{"https://example.com/secure", "http://external.com/sponsor"}},
+        {"AllOf", []LinkFilter{AllOf(FilterExtensions(".pdf"), FilterRel("ugc"))}, []string{"http://external.com/ugc.PDF"}},
+        {"Callback", []LinkFilter{func(link Link) bool { return link.Text == "About" }}, []string{"http://blog.example.com/about"}},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            links, _ := doc.Links(GetLinksProps{Filters: tt.filters})
+            if strings.Join(links, ",") != strings.Join(tt.expectedLinks, ",") {
+                t.Errorf("Expected links %v, got %v", tt.expectedLinks, links)
+            }
+        })
+    }
+}
@@ -0,0 +1,159 @@
+package katsuragi
+
+import (
+	"bufio"
+	"bytes"
+	Url "net/url"
+	"strings"
+)
+
+// robotsTxt is a parsed robots.txt file
+type robotsTxt struct {
+    groups   []robotsGroup
+    sitemaps []string
+}
+
+type robotsGroup struct {
+    agents []string // lowercase user agent tokens
+    rules  []robotsRule
+}
+
+type robotsRule struct {
+    allow bool
+    path  string
+}
+
+// parseRobotsTxt parses a robots.txt file. Unknown and invalid lines are ignored.
+func parseRobotsTxt(data []byte) *robotsTxt {
+    robots := &robotsTxt{}
+    var current *robotsGroup
+    // consecutive User-agent lines share the same group
+    lastWasAgent := false
+
+    scanner := bufio.NewScanner(bytes.NewReader(data))
+    for scanner.Scan() {
+        line, _, _ := strings.Cut(scanner.Text(), "#")
+        key, value, found := strings.Cut(line, ":")
+        if !found {
+            continue
+        }
+        key = strings.ToLower(strings.TrimSpace(key))
+        value = strings.TrimSpace(value)
+
+        switch key {
+        case "user-agent":
+            if !lastWasAgent || current == nil {
+                robots.groups = append(robots.groups, robotsGroup{})
+                current = &robots.groups[len(robots.groups)-1]
+            }
+            current.agents = append(current.agents, strings.ToLower(value))
+            lastWasAgent = true
+            continue
+        case "allow", "disallow":
+            // an empty Disallow allows everything
+            if current != nil && value != "" {
+                current.rules = append(current.rules, robotsRule{allow: key == "allow", path: value})
+            }
+        case "sitemap":
+            if value != "" {
+                robots.sitemaps = append(robots.sitemaps, value)
+            }
+        }
+        lastWasAgent = false
+    }
+    return robots
+}
+
+// allowed checks if the user agent may fetch the URL. The group of the most specific matching user agent is used,
+// falling back to "*". Within the group, the longest matching rule wins, Allow wins ties.
+func (r *robotsTxt) allowed(userAgent string, rawURL string) bool {
+    if r == nil {
+        return true
+    }
+    path := "/"
+    if parsedUrl, err := Url.Parse(rawURL); err == nil {
+        path = parsedUrl.EscapedPath()
+        if path == "" {
+            path = "/"
+        }
+        if parsedUrl.RawQuery != "" {
+            path += "?" + parsedUrl.RawQuery
+        }
+    }
+
+    group := r.findGroup(userAgent)
+    if group == nil {
+        return true
+    }
+    allowed, matchLength := true, -1
+    for _, rule := range group.rules {
+        if !matchRobotsPattern(rule.path, path) {
+            continue
+        }
+        if len(rule.path) > matchLength || (len(rule.path) == matchLength && rule.allow) {
+            allowed, matchLength = rule.allow, len(rule.path)
+        }
+    }
+    return allowed
+}
+
+// findGroup returns the group of the longest agent token contained in the user agent, or the "*" group
+func (r *robotsTxt) findGroup(userAgent string) *robotsGroup {
+    userAgent = strings.ToLower(userAgent)
+    var best, wildcard *robotsGroup
+    bestLength := 0
+    for i := range r.groups {
+        for _, agent := range r.groups[i].agents {
+            if agent == "*" {
+                if wildcard == nil {
+                    wildcard = &r.groups[i]
+                }
+            } else if userAgent != "" && strings.Contains(userAgent, agent) && len(agent) > bestLength {
+                best, bestLength = &r.groups[i], len(agent)
+            }
+        }
+    }
+    if best != nil {
+        return best
+    }
+    return wildcard
+}
+
+// matchRobotsPattern matches a path against a robots.txt pattern, supporting the `*` wildcard and the `$` end anchor
+func matchRobotsPattern(pattern string, path string) bool {
+    anchored := strings.HasSuffix(pattern, "$")
+    pattern = strings.TrimSuffix(pattern, "$")
+    parts := strings.Split(pattern, "*")
+
+    // the first part must be a prefix
+    if !strings.HasPrefix(path, parts[0]) {
+        return false
+    }
+    rest := path[len(parts[0]):]
+    for i, part := range parts[1:] {
+        // the last part of an anchored pattern must match the end of the path
+        if anchored && i == len(parts)-2 {
+            return strings.HasSuffix(rest, part)
+        }
+        index := strings.Index(rest, part)
+        if index == -1 {
+            return false
+        }
+        rest = rest[index+len(part):]
+    }
+    return !anchored || rest == ""
+}
+
+// getRobotsTxt fetches and parses the robots.txt of the URL's host.
+// A missing or unreachable robots.txt allows everything, the same way crawlers usually handle it.
+func getRobotsTxt(rawURL string, f *Fetcher) *robotsTxt {
+    parsedUrl, err := Url.Parse(rawURL)
+    if err != nil {
+        return nil
+    }
+    data, _, err := fetchResource(parsedUrl.Scheme+"://"+parsedUrl.Host+"/robots.txt", f)
+    if err != nil {
+        return nil
+    }
+    return parseRobotsTxt(data)
+}
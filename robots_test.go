@@ -0,0 +1,83 @@
+package katsuragi
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRobotsTxt(t *testing.T) {
+    robots := parseRobotsTxt([]byte(`# comment
+User-agent: *
+Disallow: /private/
+Allow: /private/public
+Disallow: /*.pdf$
+Disallow: /search?
+
+User-agent: katsuragibot
+User-agent: otherbot
+Disallow: /
+
+User-agent: emptybot
+Disallow:
+
+Sitemap: https://www.example.com/sitemap.xml
+Sitemap: https://www.example.com/news.xml # news
+`))
+
+    if !reflect.DeepEqual(robots.sitemaps, []string{"https://www.example.com/sitemap.xml", "https://www.example.com/news.xml"}) {
+        t.Errorf("sitemaps = %v", robots.sitemaps)
+    }
+
+    tests := []struct {
+        userAgent string
+        url       string
+        want      bool
+    }{
+        {"Mozilla/5.0", "https://www.example.com/", true},
+        {"Mozilla/5.0", "https://www.example.com/private/page", false},
+        {"Mozilla/5.0", "https://www.example.com/private/public/page", true},
+        {"Mozilla/5.0", "https://www.example.com/files/report.pdf", false},
+        {"Mozilla/5.0", "https://www.example.com/files/report.pdf?download=1", true},
+        {"Mozilla/5.0", "https://www.example.com/search?q=test", false},
+        {"Mozilla/5.0", "https://www.example.com/search", true},
+        {"", "https://www.example.com/private/page", false},
+        {"KatsuragiBot/1.0", "https://www.example.com/", false},
+        {"OtherBot", "https://www.example.com/page", false},
+        {"EmptyBot/2.0", "https://www.example.com/private/page", true},
+    }
+    for _, tt := range tests {
+        if got := robots.allowed(tt.userAgent, tt.url); got != tt.want {
+            t.Errorf("allowed(%q, %q) = %v, want %v", tt.userAgent, tt.url, got, tt.want)
+        }
+    }
+
+    // missing robots.txt allows everything
+    var missing *robotsTxt
+    if !missing.allowed("", "https://www.example.com/private/page") {
+        t.Errorf("missing robots.txt should allow everything")
+    }
+}
+
+func TestMatchRobotsPattern(t *testing.T) {
+    tests := []struct {
+        pattern string
+        path    string
+        want    bool
+    }{
+        {"/", "/anything", true},
+        {"/fish", "/fish.html", true},
+        {"/fish", "/Fish.html", false},
+        {"/fish*.php", "/fish/salmon.php", true},
+        {"/fish*.php", "/fish/salmon.html", false},
+        {"/*.php$", "/index.php", true},
+        {"/*.php$", "/index.php?x=1", false},
+        {"/exact$", "/exact", true},
+        {"/exact$", "/exactly", false},
+        {"/a*b*c", "/a-b-c-d", true},
+    }
+    for _, tt := range tests {
+        if got := matchRobotsPattern(tt.pattern, tt.path); got != tt.want {
+            t.Errorf("matchRobotsPattern(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+        }
+    }
+}
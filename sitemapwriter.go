@@ -0,0 +1,302 @@
+package katsuragi
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	Url "net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+    // defaultSitemapEntriesConcurrency and defaultSitemapEntriesPerHostConcurrency bound the requests of GetSitemapEntries
+    defaultSitemapEntriesConcurrency        = 10
+    defaultSitemapEntriesPerHostConcurrency = 2
+    maxSitemapURLs                          = 50000
+    sitemapXMLHeader                        = `<?xml version="1.0" encoding="UTF-8"?>` + "\n"
+    sitemapNamespace                        = `http://www.sitemaps.org/schemas/sitemap/0.9`
+    sitemapImageNamespace                   = `http://www.google.com/schemas/sitemap-image/1.1`
+    sitemapNewsNamespace                    = `http://www.google.com/schemas/sitemap-news/0.9`
+    sitemapXhtmlNamespace                   = `http://www.w3.org/1999/xhtml`
+)
+
+// GetSitemapEntries creates the sitemap entries of the given URLs (e.g. collected with GetLinks or a Crawler),
+// taking the lastmod of every page from its Last-Modified header. Duplicated URLs (see normalizeURL) are listed once
+// and pages which cannot be fetched or respond with an error status are left out.
+func (f *Fetcher) GetSitemapEntries(urls []string) ([]SitemapEntry, error) {
+    var unique []string
+    seen := make(map[string]bool)
+    for _, url := range urls {
+        if key := normalizeURL(url); !seen[key] {
+            seen[key] = true
+            unique = append(unique, url)
+        }
+    }
+
+    client := f.newHTTPClient()
+    limiter := newHostLimiter(defaultSitemapEntriesConcurrency, defaultSitemapEntriesPerHostConcurrency)
+    entries := make([]*SitemapEntry, len(unique))
+    var wg sync.WaitGroup
+    for i, url := range unique {
+        wg.Add(1)
+        go func(i int, url string) {
+            defer wg.Done()
+            host := ""
+            if parsedUrl, err := Url.Parse(url); err == nil {
+                host = parsedUrl.Host
+            }
+            release := limiter.acquire(host)
+            defer release()
+            if lastMod, ok := fetchLastModified(client, url); ok {
+                entries[i] = &SitemapEntry{Loc: url, LastMod: lastMod}
+            }
+        }(i, url)
+    }
+    wg.Wait()
+
+    var result []SitemapEntry
+    for _, entry := range entries {
+        if entry != nil {
+            result = append(result, *entry)
+        }
+    }
+    if len(result) == 0 {
+        return nil, fmt.Errorf("GetSitemapEntries failed to fetch any of the URLs")
+    }
+    return result, nil
+}
+
+// fetchLastModified requests the URL with HEAD, falling back to GET, and returns its Last-Modified header
+// (zero if missing) and whether the page responded with a 2xx status
+func fetchLastModified(client *http.Client, url string) (time.Time, bool) {
+    for _, method := range []string{"HEAD", "GET"} {
+        req, err := http.NewRequest(method, url, nil)
+        if err != nil {
+            return time.Time{}, false
+        }
+        httpResp, err := client.Do(req)
+        if err != nil {
+            continue
+        }
+        httpResp.Body.Close()
+        if method == "HEAD" && (httpResp.StatusCode == http.StatusMethodNotAllowed || httpResp.StatusCode == http.StatusNotImplemented) {
+            continue
+        }
+        if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+            return time.Time{}, false
+        }
+        lastMod, _ := http.ParseTime(httpResp.Header.Get("Last-Modified"))
+        return lastMod, true
+    }
+    return time.Time{}, false
+}
+
+// WriteSitemaps produces the sitemap XML of the entries. A single file named after props.Name is produced
+// if the entries fit in the MaxURLs and MaxBytes limits, otherwise the entries are split into numbered files
+// ("sitemap-1.xml", "sitemap-2.xml"...) listed by a sitemap index named after props.Name, returned first.
+// Priorities are written when set (nil priorities are left to the 0.5 default of the protocol),
+// as well as the image, news and hreflang extensions.
+func WriteSitemaps(entries []SitemapEntry, props SitemapWriterProps) ([]SitemapFile, error) {
+    if len(entries) == 0 {
+        return nil, fmt.Errorf("WriteSitemaps failed to write sitemap. No entries")
+    }
+    if props.Name == "" {
+        props.Name = "sitemap"
+    }
+    if props.MaxURLs <= 0 || props.MaxURLs > maxSitemapURLs {
+        props.MaxURLs = maxSitemapURLs
+    }
+    if props.MaxBytes <= 0 || props.MaxBytes > maxSitemapSize {
+        props.MaxBytes = maxSitemapSize
+    }
+    if props.BaseURL == "" {
+        parsedUrl, err := Url.Parse(entries[0].Loc)
+        if err != nil || parsedUrl.Host == "" {
+            return nil, fmt.Errorf("WriteSitemaps failed to find the base URL of the sitemap")
+        }
+        props.BaseURL = parsedUrl.Scheme + "://" + parsedUrl.Host + "/"
+    } else if !strings.HasSuffix(props.BaseURL, "/") {
+        // file names are resolved against the base URL, "https://example.com/sitemaps" would drop "sitemaps"
+        props.BaseURL += "/"
+    }
+    extension := ".xml"
+    if props.Gzip {
+        extension = ".xml.gz"
+    }
+
+    // split the <url> elements into chunks within the limits
+    type chunk struct {
+        body    bytes.Buffer
+        urls    int
+        lastMod time.Time
+        images  bool
+        news    bool
+        xhtml   bool
+    }
+    var chunks []*chunk
+    // namespace declarations are counted for every chunk, as if all extensions were used
+    overhead := len(sitemapXMLHeader) + len(sitemapUrlsetOpening(true, true, true)) + len("</urlset>\n")
+    for _, entry := range entries {
+        element := sitemapURLElement(entry)
+        if overhead+len(element) > props.MaxBytes {
+            return nil, fmt.Errorf("WriteSitemaps failed to write sitemap. Entry too large: %v", entry.Loc)
+        }
+        if len(chunks) == 0 || chunks[len(chunks)-1].urls >= props.MaxURLs || overhead+chunks[len(chunks)-1].body.Len()+len(element) > props.MaxBytes {
+            chunks = append(chunks, &chunk{})
+        }
+        current := chunks[len(chunks)-1]
+        current.body.Write(element)
+        current.urls++
+        if entry.LastMod.After(current.lastMod) {
+            current.lastMod = entry.LastMod
+        }
+        current.images = current.images || len(entry.Images) > 0
+        current.news = current.news || entry.News != nil
+        current.xhtml = current.xhtml || len(entry.Alternates) > 0
+    }
+
+    var files []SitemapFile
+    for i, c := range chunks {
+        name := props.Name + extension
+        if len(chunks) > 1 {
+            name = props.Name + "-" + strconv.Itoa(i+1) + extension
+        }
+        var data bytes.Buffer
+        data.WriteString(sitemapXMLHeader)
+        data.WriteString(sitemapUrlsetOpening(c.images, c.news, c.xhtml))
+        data.Write(c.body.Bytes())
+        data.WriteString("</urlset>\n")
+        files = append(files, SitemapFile{
+            Name: name,
+            URL:  ensureAbsoluteURL(name, props.BaseURL),
+            Data: data.Bytes(),
+            URLs: c.urls,
+        })
+    }
+
+    if len(files) > 1 {
+        if len(files) > maxSitemapURLs {
+            return nil, fmt.Errorf("WriteSitemaps failed to write sitemap index. Too many sitemaps: %v", len(files))
+        }
+        var index bytes.Buffer
+        index.WriteString(sitemapXMLHeader)
+        index.WriteString(`<sitemapindex xmlns="` + sitemapNamespace + `">` + "\n")
+        for i, file := range files {
+            index.WriteString("  <sitemap>\n")
+            writeSitemapTag(&index, "    ", "loc", file.URL)
+            if !chunks[i].lastMod.IsZero() {
+                writeSitemapTag(&index, "    ", "lastmod", chunks[i].lastMod.UTC().Format(time.RFC3339))
+            }
+            index.WriteString("  </sitemap>\n")
+        }
+        index.WriteString("</sitemapindex>\n")
+        name := props.Name + extension
+        files = append([]SitemapFile{{
+            Name:  name,
+            URL:   ensureAbsoluteURL(name, props.BaseURL),
+            Data:  index.Bytes(),
+            URLs:  len(files),
+            Index: true,
+        }}, files...)
+    }
+
+    if props.Gzip {
+        for i := range files {
+            var compressed bytes.Buffer
+            writer := gzip.NewWriter(&compressed)
+            writer.Write(files[i].Data)
+            if err := writer.Close(); err != nil {
+                return nil, err
+            }
+            files[i].Data = compressed.Bytes()
+        }
+    }
+    return files, nil
+}
+
+// SaveSitemaps writes the sitemap files produced by WriteSitemaps to the directory
+func SaveSitemaps(dir string, files []SitemapFile) error {
+    for _, file := range files {
+        if err := os.WriteFile(filepath.Join(dir, file.Name), file.Data, 0644); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// sitemapUrlsetOpening returns the <urlset> tag declaring the namespaces of the extensions in use
+func sitemapUrlsetOpening(images bool, news bool, xhtml bool) string {
+    opening := `<urlset xmlns="` + sitemapNamespace + `"`
+    if images {
+        opening += ` xmlns:image="` + sitemapImageNamespace + `"`
+    }
+    if news {
+        opening += ` xmlns:news="` + sitemapNewsNamespace + `"`
+    }
+    if xhtml {
+        opening += ` xmlns:xhtml="` + sitemapXhtmlNamespace + `"`
+    }
+    return opening + ">\n"
+}
+
+// sitemapURLElement returns the <url> element of the entry
+func sitemapURLElement(entry SitemapEntry) []byte {
+    var element bytes.Buffer
+    element.WriteString("  <url>\n")
+    writeSitemapTag(&element, "    ", "loc", entry.Loc)
+    if !entry.LastMod.IsZero() {
+        writeSitemapTag(&element, "    ", "lastmod", entry.LastMod.UTC().Format(time.RFC3339))
+    }
+    if entry.ChangeFreq != "" {
+        writeSitemapTag(&element, "    ", "changefreq", entry.ChangeFreq)
+    }
+    if entry.Priority != nil && *entry.Priority >= 0 && *entry.Priority <= 1 {
+        writeSitemapTag(&element, "    ", "priority", strconv.FormatFloat(*entry.Priority, 'f', -1, 64))
+    }
+    for _, alternate := range entry.Alternates {
+        element.WriteString(`    <xhtml:link rel="alternate" hreflang="`)
+        xml.EscapeText(&element, []byte(alternate.Hreflang))
+        element.WriteString(`" href="`)
+        xml.EscapeText(&element, []byte(alternate.Href))
+        element.WriteString("\"/>\n")
+    }
+    for _, image := range entry.Images {
+        element.WriteString("    <image:image>\n")
+        writeSitemapTag(&element, "      ", "image:loc", image.Loc)
+        if image.Title != "" {
+            writeSitemapTag(&element, "      ", "image:title", image.Title)
+        }
+        if image.Caption != "" {
+            writeSitemapTag(&element, "      ", "image:caption", image.Caption)
+        }
+        element.WriteString("    </image:image>\n")
+    }
+    if entry.News != nil {
+        element.WriteString("    <news:news>\n      <news:publication>\n")
+        writeSitemapTag(&element, "        ", "news:name", entry.News.PublicationName)
+        writeSitemapTag(&element, "        ", "news:language", entry.News.PublicationLanguage)
+        element.WriteString("      </news:publication>\n")
+        if !entry.News.PublicationDate.IsZero() {
+            writeSitemapTag(&element, "      ", "news:publication_date", entry.News.PublicationDate.UTC().Format(time.RFC3339))
+        }
+        writeSitemapTag(&element, "      ", "news:title", entry.News.Title)
+        element.WriteString("    </news:news>\n")
+    }
+    element.WriteString("  </url>\n")
+    return element.Bytes()
+}
+
+// writeSitemapTag writes an indented tag with escaped text on its own line
+func writeSitemapTag(w io.Writer, indent string, tag string, text string) {
+    io.WriteString(w, indent+"<"+tag+">")
+    xml.EscapeText(w, []byte(text))
+    io.WriteString(w, "</"+tag+">\n")
+}
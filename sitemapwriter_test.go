@@ -0,0 +1,173 @@
+package katsuragi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func floatPtr(value float64) *float64 {
+    return &value
+}
+
+func TestWriteSitemaps(t *testing.T) {
+    lastMod := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+    entries := []SitemapEntry{
+        {Loc: "https://www.example.com/?a=1&b=2", LastMod: lastMod, ChangeFreq: "daily", Priority: floatPtr(0.8)},
+        {Loc: "https://www.example.com/about", Priority: floatPtr(0.75)},
+        {Loc: "https://www.example.com/archive", Priority: floatPtr(0)},
+        {Loc: "https://www.example.com/article",
+            Images:     []SitemapImage{{Loc: "https://www.example.com/photo.jpg", Title: "Photo"}},
+            Alternates: []SitemapAlternate{{Hreflang: "de", Href: "https://www.example.com/de/article"}},
+            News: &SitemapNews{PublicationName: "Example", PublicationLanguage: "en", PublicationDate: lastMod, Title: "News"}},
+    }
+
+    files, err := WriteSitemaps(entries, SitemapWriterProps{})
+    if err != nil {
+        t.Fatalf("WriteSitemaps() error = %v", err)
+    }
+    if len(files) != 1 || files[0].Name != "sitemap.xml" || files[0].URL != "https://www.example.com/sitemap.xml" || files[0].URLs != 4 || files[0].Index {
+        t.Fatalf("unexpected files %+v", files)
+    }
+    data := string(files[0].Data)
+    for _, want := range []string{"<loc>https://www.example.com/?a=1&amp;b=2</loc>", "<priority>0.8</priority>", "<priority>0.75</priority>", "<priority>0</priority>", `xmlns:image=`, `xmlns:news=`, `xmlns:xhtml=`} {
+        if !strings.Contains(data, want) {
+            t.Errorf("sitemap does not contain %q:\n%s", want, data)
+        }
+    }
+
+    if count := strings.Count(data, "<priority>"); count != 3 {
+        t.Errorf("expected 3 priorities, the unset one being omitted, got %d", count)
+    }
+
+    // the written sitemap parses back to the same entries
+    parsed, err := ParseSitemap(files[0].Data, files[0].URL)
+    if err != nil {
+        t.Fatalf("ParseSitemap() error = %v", err)
+    }
+    for i := range entries {
+        entries[i].Sitemap = files[0].URL
+    }
+    if !reflect.DeepEqual(parsed.Entries, entries) {
+        t.Errorf("round trip = %+v, want %+v", parsed.Entries, entries)
+    }
+
+    if _, err := WriteSitemaps(nil, SitemapWriterProps{}); err == nil {
+        t.Errorf("expected an error without entries")
+    }
+}
+
+func TestWriteSitemapsSplit(t *testing.T) {
+    var entries []SitemapEntry
+    for i := 0; i < 5; i++ {
+        entries = append(entries, SitemapEntry{Loc: "https://www.example.com/page-" + strconv.Itoa(i), LastMod: time.Date(2024, 1, i+1, 0, 0, 0, 0, time.UTC)})
+    }
+
+    // room for two <url> elements per file
+    maxBytes := len(sitemapXMLHeader) + len(sitemapUrlsetOpening(true, true, true)) + len("</urlset>\n") + 2*len(sitemapURLElement(entries[0]))
+
+    tests := []struct {
+        name  string
+        props SitemapWriterProps
+        want  []int // URLs of the sitemaps, after the index
+    }{
+        {"URL limit", SitemapWriterProps{MaxURLs: 2, BaseURL: "https://cdn.example.com/maps/"}, []int{2, 2, 1}},
+        // the trailing slash of the base URL is added
+        {"Size limit", SitemapWriterProps{MaxBytes: maxBytes, BaseURL: "https://cdn.example.com/maps", Gzip: true}, []int{2, 2, 1}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            files, err := WriteSitemaps(entries, tt.props)
+            if err != nil {
+                t.Fatalf("WriteSitemaps() error = %v", err)
+            }
+            extension := ".xml"
+            if tt.props.Gzip {
+                extension = ".xml.gz"
+            }
+            if len(files) != len(tt.want)+1 || !files[0].Index || files[0].Name != "sitemap"+extension {
+                t.Fatalf("unexpected files %+v", files)
+            }
+            index, err := ParseSitemap(files[0].Data, files[0].URL)
+            if err != nil {
+                t.Fatalf("ParseSitemap() error = %v", err)
+            }
+            var total int
+            for i, file := range files[1:] {
+                wantName := "sitemap-" + strconv.Itoa(i+1) + extension
+                if file.Name != wantName || file.URLs != tt.want[i] || index.Sitemaps[i] != "https://cdn.example.com/maps/"+wantName {
+                    t.Errorf("file %d = %v (%d URLs), index %v", i, file.Name, file.URLs, index.Sitemaps[i])
+                }
+                if tt.props.MaxBytes > 0 && !tt.props.Gzip && len(file.Data) > tt.props.MaxBytes {
+                    t.Errorf("file %v exceeds the size limit", file.Name)
+                }
+                sitemap, err := ParseSitemap(file.Data, file.URL)
+                if err != nil {
+                    t.Fatalf("ParseSitemap() error = %v", err)
+                }
+                total += len(sitemap.Entries)
+            }
+            if total != len(entries) {
+                t.Errorf("total entries = %d, want %d", total, len(entries))
+            }
+        })
+    }
+
+    if _, err := WriteSitemaps(entries, SitemapWriterProps{MaxBytes: 100}); err == nil {
+        t.Errorf("expected an error for an entry larger than the size limit")
+    }
+
+    dir := t.TempDir()
+    files, _ := WriteSitemaps(entries, SitemapWriterProps{MaxURLs: 2})
+    if err := SaveSitemaps(dir, files); err != nil {
+        t.Fatalf("SaveSitemaps() error = %v", err)
+    }
+    if _, err := os.Stat(filepath.Join(dir, "sitemap-3.xml")); err != nil {
+        t.Errorf("sitemap file not saved: %v", err)
+    }
+}
+
+func TestGetSitemapEntries(t *testing.T) {
+    lastMod := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        switch r.URL.Path {
+        case "/dated":
+            w.Header().Set("Last-Modified", lastMod.Format(http.TimeFormat))
+        case "/nohead":
+            if r.Method == "HEAD" {
+                w.WriteHeader(http.StatusMethodNotAllowed)
+                return
+            }
+            w.Header().Set("Last-Modified", lastMod.Format(http.TimeFormat))
+        case "/undated":
+        default:
+            w.WriteHeader(http.StatusNotFound)
+        }
+    }))
+    defer server.Close()
+
+    entries, err := NewFetcher(nil).GetSitemapEntries([]string{
+        server.URL + "/dated", server.URL + "/undated", server.URL + "/missing", server.URL + "/nohead", server.URL + "/dated#top",
+    })
+    if err != nil {
+        t.Fatalf("GetSitemapEntries() error = %v", err)
+    }
+    want := []SitemapEntry{
+        {Loc: server.URL + "/dated", LastMod: lastMod},
+        {Loc: server.URL + "/undated"},
+        {Loc: server.URL + "/nohead", LastMod: lastMod},
+    }
+    if !reflect.DeepEqual(entries, want) {
+        t.Errorf("GetSitemapEntries() = %+v, want %+v", entries, want)
+    }
+
+    if _, err := NewFetcher(nil).GetSitemapEntries([]string{server.URL + "/missing"}); err == nil {
+        t.Errorf("expected an error when no URL can be fetched")
+    }
+}
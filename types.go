@@ -3,6 +3,7 @@ package katsuragi
 import (
 	"container/list"
 	"net/http"
+	"regexp"
 	"sync"
 	"time"
 
@@ -47,9 +48,477 @@ func NewFetcher(props *FetcherProps) *Fetcher {
     }
 }
 
+// FaviconHandlerProps configures FaviconHandler
+type FaviconHandlerProps struct {
+    CacheCap int  // number of icons (URL and size) kept in memory, 1000 by default
+    // AllowSVG serves the SVG icons of websites inline. By default they are served as attachments,
+    // since an SVG opened directly may run scripts (the Content-Security-Policy sandbox blocks them in browsers supporting it)
+    AllowSVG bool
+}
+
 type GetLinksProps struct {
     Url      string
-    Category string
+    Category LinkCategory // categories only apply to web links
+    Kinds    []LinkKind   // every link with a valid domain by default (web, fragment and other links)
+    Filters  []LinkFilter // all filters must match, see FilterSchemes, FilterInclude, FilterRel...
+    Dedupe   LinkDedupe   // no deduplication by default
+    Sort     LinkSort     // document order by default
+}
+
+// LinkDedupe selects how duplicated links are merged
+type LinkDedupe string
+
+const (
+    LinkDedupeNone       LinkDedupe = ""           // keep duplicates
+    LinkDedupeExact      LinkDedupe = "exact"      // same resolved URL
+    LinkDedupeNormalized LinkDedupe = "normalized" // same URL after normalization, see normalizeURL
+)
+
+// LinkSort selects the order of the links
+type LinkSort string
+
+const (
+    LinkSortDocument LinkSort = ""      // document order (first position of deduplicated links)
+    LinkSortURL      LinkSort = "url"   // alphabetical order of the URLs
+    LinkSortCount    LinkSort = "count" // most frequent links first, then document order. Requires a dedupe mode
+)
+
+// LinkKind classifies links by their target
+type LinkKind string
+
+const (
+    LinkKindWeb      LinkKind = "web"      // http(s) and relative links
+    LinkKindEmail    LinkKind = "email"    // mailto:
+    LinkKindPhone    LinkKind = "phone"    // tel:
+    LinkKindFragment LinkKind = "fragment" // #section
+    LinkKindScript   LinkKind = "script"   // javascript:
+    LinkKindOther    LinkKind = "other"    // any other scheme (ftp:, sms:, data:...)
+)
+
+// LinkCategory selects links by their relation to the page URL
+type LinkCategory string
+
+const (
+    LinkCategoryAll           LinkCategory = "all"
+    LinkCategoryInternal      LinkCategory = "internal"       // same registrable domain (example.com, www.example.com, blog.example.com)
+    LinkCategoryExternal      LinkCategory = "external"       // different registrable domain
+    LinkCategorySameDomain    LinkCategory = "same-domain"    // alias of "internal"
+    LinkCategorySameHost      LinkCategory = "same-host"      // exactly the same host
+    LinkCategorySameSubdomain LinkCategory = "same-subdomain" // the same host or one of its subdomains
+)
+
+// LinkFilter is a predicate selecting links, see GetLinksProps
+type LinkFilter func(Link) bool
+
+// Document is a parsed HTML page which can be analysed without a Fetcher,
+// see ParseDocument, ParseString and ParseFile
+type Document struct {
+    root      *html.Node
+    url       string
+    resources []Resource    // captured before scripts and styles are removed, see newDocument
+    response  *ResponseInfo // nil for documents parsed with ParseDocument
+}
+
+// IconSource describes where an icon was discovered
+type IconSource string
+
+const (
+    IconSourceLink          IconSource = "link"          // <link rel="icon"> and similar tags
+    IconSourceOpenGraph     IconSource = "og:image"      // square <meta property="og:image">
+    IconSourceManifest      IconSource = "manifest"      // Web App Manifest icons
+    IconSourceMSApplication IconSource = "msapplication" // <meta name="msapplication-TileImage">
+    IconSourceBrowserConfig IconSource = "browserconfig" // browserconfig.xml tiles
+    IconSourceRoot          IconSource = "root"          // /favicon.ico in the root directory
+)
+
+type IconSize struct {
+    Width  int
+    Height int
+}
+
+// Icon is a favicon along with the attributes declared for it
+type Icon struct {
+    URL     string
+    Rel     string
+    Sizes   []IconSize // declared sizes, empty if not declared
+    AnySize bool       // sizes="any", usually a scalable (SVG) icon
+    Type    string     // declared MIME type
+    Media   string
+    Color   string     // mask-icon or Windows tile color
+    Purpose []string   // manifest icon purpose ("any", "maskable", "monochrome")
+    Source  IconSource
+    // Measured is set when Sizes were measured from the downloaded image instead of declared, see GetBestIcon
+    Measured bool
+}
+
+// IconImage is a downloaded and decoded icon, see GetIconImage
+type IconImage struct {
+    URL      string
+    Data     []byte
+    Format   string     // "png", "jpeg", "gif", "webp", "ico" or "svg"
+    MIMEType string
+    Width    int        // actual width in pixels, the largest image for ICO files
+    Height   int        // actual height in pixels, the largest image for ICO files
+    Images   []IconSize // sizes of all images of an ICO file
+}
+
+// Manifest is a parsed Web App Manifest, see GetManifest
+type Manifest struct {
+    URL             string
+    Name            string
+    ShortName       string
+    Description     string
+    StartURL        string
+    Scope           string
+    Display         string
+    ThemeColor      string
+    BackgroundColor string
+    Icons           []Icon
+}
+
+// Link is an <a> tag of a page, see GetLinkDetails
+type Link struct {
+    Href     string   // raw href attribute
+    URL      string   // resolved absolute URL
+    Kind     LinkKind
+    Text     string   // anchor text, falls back to the alt text of images and aria-label
+    Rel      []string // lowercase rel tokens (nofollow, sponsored, ugc, noopener...)
+    Title    string
+    Target   string
+    Hreflang string
+    Position int      // 1-based position of the link among all links of the page
+    Section  string   // closest "nav", "header", "footer", "main" or "aside" ancestor, empty if none
+    // Occurrences is the number of times the link appears in the page when links are deduplicated
+    // (the details of the first occurrence are kept), 0 otherwise
+    Occurrences int
+}
+
+// ResourceKind classifies the resources referenced by a page
+type ResourceKind string
+
+const (
+    ResourceKindImage      ResourceKind = "image"      // img src/srcset, picture source, video poster
+    ResourceKindVideo      ResourceKind = "video"      // video src and source
+    ResourceKindAudio      ResourceKind = "audio"      // audio src and source
+    ResourceKindIframe     ResourceKind = "iframe"
+    ResourceKindStylesheet ResourceKind = "stylesheet" // link rel="stylesheet"
+    ResourceKindPreload    ResourceKind = "preload"    // link rel="preload" and rel="modulepreload"
+    ResourceKindScript     ResourceKind = "script"     // script src
+    ResourceKindForm       ResourceKind = "form"       // form action
+    ResourceKindCSS        ResourceKind = "css"        // url() of style attributes and <style> tags
+)
+
+// Resource is a URL referenced by a page, see GetResources
+type Resource struct {
+    URL  string // resolved absolute URL
+    Kind ResourceKind
+    Tag  string // tag referencing the resource
+    Attr string // attribute referencing the resource, empty for <style> contents
+}
+
+// SrcsetCandidate is an image candidate of a srcset attribute
+type SrcsetCandidate struct {
+    URL        string
+    Descriptor string // width ("640w") or pixel density ("2x") descriptor, empty if not specified
+}
+
+type CheckLinksProps struct {
+    Category           LinkCategory // see GetLinksProps
+    Filters            []LinkFilter // see GetLinksProps
+    Concurrency        int          // maximum number of concurrent requests, 10 by default
+    PerHostConcurrency int          // maximum number of concurrent requests per host, 2 by default
+}
+
+// LinkStatus is the result of probing a link, see CheckLinks
+type LinkStatus struct {
+    Link       Link          // first occurrence of the link, Occurrences holds the number of occurrences
+    OK         bool          // no error and a 2xx or 3xx status code
+    Method     string        // "HEAD", or "GET" when HEAD is not supported
+    StatusCode int
+    Status     string
+    FinalURL   string        // URL after redirects
+    Redirected bool
+    Latency    time.Duration // total time spent probing the link
+    Err        error         // network error, nil if the server responded
+}
+
+// CrawlerProps configures a Crawler, see NewCrawler
+type CrawlerProps struct {
+    MaxDepth           *int             // maximum number of links followed from a seed, 3 if nil, seeds have depth 0 (0 fetches the seeds only)
+    MaxPages           int              // maximum number of pages fetched, 100 by default
+    Category           LinkCategory     // links followed from every page, relative to the page. "internal" by default
+    Filters            []LinkFilter     // filters the links followed, see GetLinksProps
+    Include            []*regexp.Regexp // if set, only URLs matching one of the patterns are followed
+    Exclude            []*regexp.Regexp // URLs matching one of the patterns are not followed
+    Concurrency        int              // maximum number of concurrent requests, 10 by default
+    PerHostConcurrency int              // maximum number of concurrent requests per host, 2 by default
+    IgnoreRobots       bool             // fetch pages disallowed by robots.txt
+}
+
+// Crawler fetches pages starting from seed URLs and follows their links, see NewCrawler
+type Crawler struct {
+    fetcher *Fetcher
+    props    CrawlerProps
+    maxDepth int                   // MaxDepth or its default
+    robots   map[string]*robotsTxt // robots.txt by scheme and host, nil if missing
+    mu       sync.Mutex
+}
+
+// CrawlResult is a page fetched by a Crawler
+type CrawlResult struct {
+    URL         string
+    Depth       int       // number of links followed from the seed
+    Referrer    string    // page linking to the URL, empty for seeds
+    Title       string
+    Description string
+    Language    *Language // nil if the language is neither declared nor detected
+    Links       []Link    // outgoing web links of the page, deduplicated
+    Err         error     // fetch error, the other fields are empty
+}
+
+// Sitemap is a parsed XML sitemap, either a urlset listing pages or a sitemap index listing other sitemaps
+type Sitemap struct {
+    URL      string
+    Entries  []SitemapEntry // pages of a urlset
+    Sitemaps []string       // sitemaps of a sitemap index
+}
+
+// SitemapEntry is a page listed in a sitemap, see GetSitemap
+type SitemapEntry struct {
+    Loc        string
+    LastMod    time.Time          // zero if missing or invalid
+    ChangeFreq string             // "always", "hourly", "daily", "weekly", "monthly", "yearly" or "never"
+    Priority   *float64           // nil if missing, the protocol then defaults to 0.5
+    Images     []SitemapImage     // image sitemap extension
+    News       *SitemapNews       // news sitemap extension
+    Alternates []SitemapAlternate // localized versions of the page (<xhtml:link rel="alternate" hreflang="...">)
+    Sitemap    string             // sitemap listing the page
+}
+
+type SitemapImage struct {
+    Loc     string
+    Title   string
+    Caption string
+}
+
+type SitemapNews struct {
+    PublicationName     string
+    PublicationLanguage string
+    PublicationDate     time.Time
+    Title               string
+}
+
+type SitemapAlternate struct {
+    Hreflang string
+    Href     string
+}
+
+// SitemapWriterProps configures WriteSitemaps
+type SitemapWriterProps struct {
+    BaseURL  string // URL the sitemap files are published at, used in the sitemap index. Origin of the first entry by default
+    Name     string // file name without extension, "sitemap" by default
+    Gzip     bool   // gzip the files and add the ".gz" extension
+    MaxURLs  int    // maximum number of URLs per file, 50,000 by default (the protocol limit)
+    MaxBytes int    // maximum uncompressed size of a file, 50 MB by default (the protocol limit)
+}
+
+// SitemapFile is a sitemap or sitemap index file produced by WriteSitemaps
+type SitemapFile struct {
+    Name  string // file name, e.g. "sitemap.xml" or "sitemap-1.xml.gz"
+    URL   string // URL the file is listed with in the sitemap index
+    Data  []byte
+    URLs  int    // number of pages, or sitemaps for an index
+    Index bool
+}
+
+type GetFeedsProps struct {
+    Parse   bool // fetch and parse every feed, filling its metadata and items
+    NoProbe bool // do not probe common feed paths (/feed, /rss.xml...) when the page links to no feed
+}
+
+// FeedType is the format of a feed
+type FeedType string
+
+const (
+    FeedTypeRSS  FeedType = "rss"
+    FeedTypeAtom FeedType = "atom"
+    FeedTypeJSON FeedType = "json" // JSON Feed
+)
+
+// Feed is an RSS, Atom or JSON feed, see GetFeeds
+type Feed struct {
+    URL         string
+    Type        FeedType
+    Title       string     // title attribute of the <link> tag, replaced with the feed title once parsed
+    Description string
+    Link        string     // website of the feed
+    Items       []FeedItem
+    Err         error      // error met while fetching or parsing the feed, see GetFeedsProps.Parse
+}
+
+// FeedItem is an item of a feed, unified across feed formats
+type FeedItem struct {
+    ID        string
+    Title     string
+    Link      string
+    Published time.Time // zero if missing or invalid
+    Updated   time.Time // zero if missing or invalid
+    Author    string
+    Summary   string    // plain text, HTML tags are removed
+}
+
+// Alternate is a language or region variant of a page, see GetAlternates
+type Alternate struct {
+    URL      string
+    Hreflang string // as declared, e.g. "en-GB" or "x-default"
+    Language string // lowercase ISO 639 language code, empty for "x-default"
+    Script   string // ISO 15924 script code, e.g. "Hant"
+    Region   string // uppercase ISO 3166 region code, e.g. "GB"
+    Source   string // "link" for <link> tags, "header" for the HTTP Link header
+}
+
+// LanguageSource describes where the language of a page was found
+type LanguageSource string
+
+const (
+    LanguageSourceHTML     LanguageSource = "html"      // <html lang="...">
+    LanguageSourceHeader   LanguageSource = "header"    // Content-Language HTTP header
+    LanguageSourceMeta     LanguageSource = "meta"      // <meta http-equiv="content-language">
+    LanguageSourceOgLocale LanguageSource = "og:locale" // <meta property="og:locale">
+    LanguageSourceDetected LanguageSource = "detected"  // statistical detection over the visible text
+)
+
+// Language is the language of a page, see GetLanguage
+type Language struct {
+    Tag        string // normalized language tag, e.g. "en-US"
+    Language   string // lowercase ISO 639 language code, e.g. "en"
+    Region     string // uppercase ISO 3166 region code, e.g. "US"
+    Source     LanguageSource
+    Confidence float64 // 1 for declared languages, between 0 and 1 for detected ones
+}
+
+// Metadata groups the metadata of a page, see GetMetadata
+type Metadata struct {
+    URL         string
+    Title       string
+    Description string
+    Canonical   string
+    Language    *Language // nil if the language is neither declared nor detected
+}
+
+// RobotsDirectives are the indexing directives of a page, see GetRobotsMeta
+type RobotsDirectives struct {
+    NoIndex          bool
+    NoFollow         bool
+    NoArchive        bool
+    NoSnippet        bool
+    NoImageIndex     bool
+    NoTranslate      bool
+    MaxSnippet       int       // maximum number of characters of a text snippet, -1 for no limit
+    MaxImagePreview  string    // "none", "standard" or "large", empty if not set
+    MaxVideoPreview  int       // maximum number of seconds of a video preview, -1 for no limit
+    UnavailableAfter time.Time // zero if not set
+    Directives       []string  // raw directives with lowercase names, e.g. "max-snippet: 50"
+}
+
+// RobotsMeta holds the directives of the robots meta tags and X-Robots-Tag headers of a page
+type RobotsMeta struct {
+    Directives RobotsDirectives            // directives for all crawlers
+    Bots       map[string]RobotsDirectives // directives for specific crawlers (e.g. "googlebot"), lowercase names
+}
+
+// ResponseInfo describes the HTTP response of a fetched page, see GetResponseInfo
+type ResponseInfo struct {
+    URL           string        // requested URL
+    FinalURL      string        // URL after redirects
+    StatusCode    int
+    Status        string
+    Proto         string        // e.g. "HTTP/1.1"
+    Header        http.Header
+    ContentType   string        // media type of the Content-Type header, lowercase without parameters
+    ContentLength int64         // size of the body read, or the Content-Length header when the body is not read (-1 if unknown)
+    Duration      time.Duration // time from sending the request to reading the body
+    FetchedAt     time.Time
+}
+
+// Article is the main content of a page, see GetArticle
+type Article struct {
+    URL         string
+    Title       string
+    Byline      string        // author of the article
+    Excerpt     string        // description of the page, or the first paragraph of the article
+    Published   time.Time     // zero if not found
+    LeadImage   string        // absolute URL of the main image, empty if not found
+    HTML        string        // cleaned HTML of the content, with absolute URLs
+    Text        string        // plain text of the content, one line per block
+    WordCount   int
+    ReadingTime time.Duration // estimated at 200 words per minute, rounded up to the minute
+}
+
+// HeadingIssue is a problem of the heading outline, see GetHeadings
+type HeadingIssue string
+
+const (
+    HeadingIssueMissingH1    HeadingIssue = "missing-h1"    // the page has no <h1>
+    HeadingIssueMultipleH1   HeadingIssue = "multiple-h1"   // the page has several <h1>, flagged on all but the first one
+    HeadingIssueSkippedLevel HeadingIssue = "skipped-level" // the heading skips a level after the previous heading, e.g. <h1> then <h3>
+    HeadingIssueEmpty        HeadingIssue = "empty"         // the heading has no text
+)
+
+// Heading is a <h1>-<h6> heading of the outline, see GetHeadings
+type Heading struct {
+    Level    int            // 1 to 6
+    Text     string         // visible text, or the alt text of its images
+    ID       string         // id of the heading or of its anchor, empty if not found
+    URL      string         // absolute URL of the heading anchor, empty without ID
+    Position int            // index of the heading in document order, from 0
+    Issues   []HeadingIssue
+    Children []*Heading     // headings of lower levels until the next heading of the same or a higher level
+}
+
+// Outline is the heading hierarchy of a page, see GetHeadings
+type Outline struct {
+    Headings []*Heading     // top-level headings
+    Count    int            // total number of headings
+    Issues   []HeadingIssue // issues found in the outline, once each
+}
+
+// ImageSource tells where an image of a page was found, see GetImages
+type ImageSource string
+
+const (
+    ImageSourceImg     ImageSource = "img"           // <img> tag
+    ImageSourcePicture ImageSource = "picture"       // <source> of a <picture>
+    ImageSourceCSS     ImageSource = "css"           // url() of an inline style, e.g. background-image
+    ImageSourceOg      ImageSource = "og:image"      // og:image meta tag
+    ImageSourceTwitter ImageSource = "twitter:image" // twitter:image meta tag
+)
+
+// ImageIssue is an accessibility or layout problem of an image, see GetImages
+type ImageIssue string
+
+const (
+    ImageIssueMissingSrc        ImageIssue = "missing-src"        // the <img> has no src, srcset, data-src or data-srcset
+    ImageIssueMissingAlt        ImageIssue = "missing-alt"        // the <img> has no alt attribute (alt="" marks decorative images)
+    ImageIssueMissingDimensions ImageIssue = "missing-dimensions" // the <img> does not declare both width and height
+)
+
+// Image is an image of a page, see GetImages
+type Image struct {
+    URL        string            // resolved absolute src (data: URLs are kept), else the first srcset candidate, data-src or data-srcset candidate
+    Source     ImageSource
+    Alt        string
+    HasAlt     bool              // whether the alt attribute is present, alt="" being valid for decorative images
+    Width      int               // declared width, 0 if not declared
+    Height     int               // declared height, 0 if not declared
+    Loading    string            // lowercase loading attribute ("lazy" or "eager"), empty if not set
+    Srcset     []SrcsetCandidate // srcset candidates with resolved URLs
+    Sizes      string
+    Media      string            // media query of <picture> sources
+    LazySrc    string            // resolved data-src (data-lazy-src, data-original) of lazy-loading scripts
+    LazySrcset []SrcsetCandidate // resolved data-srcset (data-lazy-srcset) candidates
+    Position   int               // index of the image in document order, from 0
+    Issues     []ImageIssue      // only checked for <img> tags
 }
 
 type DomainParts struct {
@@ -60,7 +529,8 @@ type DomainParts struct {
 
 type cacheEntry struct {
     url      string
-    response *html.Node
+    document *Document
+    response *ResponseInfo // also kept for error entries of HTTP responses (status, Content-Type)
     isError  bool
     err      error
 }
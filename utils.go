@@ -1,7 +1,9 @@
 package katsuragi
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"net/http"
 	Url "net/url"
 	"strings"
@@ -13,78 +15,202 @@ import (
 
 // --- Generic utils ---
 func retrieveHTML(url string, f *Fetcher) (*html.Node, error) {
-    cachedValue, found, cachedErr := f.GetFromCache(url)
-    if found {
-        if cachedErr != nil {
-            return nil, cachedErr
-        }
-        return cachedValue, nil
+    document, err := retrieveDocument(url, f)
+    if err != nil || document == nil {
+        return nil, err
     }
+    return document.root, nil
+}
 
-    timeout := time.Duration(f.props.Timeout) * time.Millisecond
+// retrieveDocument fetches and parses the HTML of the URL, see retrieveHTML.
+// The document also holds the data captured before the HTML is cleaned.
+func retrieveDocument(url string, f *Fetcher) (*Document, error) {
+    document, _, err := retrieveResponse(context.Background(), url, f)
+    return document, err
+}
 
-    var client *http.Client
-    
-    if f.props.UserAgent != "" {
-        // Create a custom transport with the User-Agent
-        transport := &http.Transport{
-        }
-        // Create a client with the custom transport and timeout
-        client = &http.Client{
-            Timeout: timeout,
-            Transport: &UserAgentTransport{
-                UserAgent: f.props.UserAgent,
-                Transport: transport,
-            },
-        }
-    } else {
-        // Create a standard client with just the timeout if no User-Agent is specified
-        client = &http.Client{
-            Timeout: timeout,
+// retrieveResponse fetches and parses the HTML of the URL like retrieveDocument, and also returns the response info.
+// The response info is returned (and cached) for HTTP error statuses and non-HTML responses too,
+// it is only nil when no response was received. The request is aborted once the context is cancelled.
+func retrieveResponse(ctx context.Context, url string, f *Fetcher) (*Document, *ResponseInfo, error) {
+    if entry, found := f.getCacheEntry(url); found {
+        if entry.isError {
+            return nil, entry.response, entry.err
         }
+        return entry.document, entry.response, nil
     }
-    
+
+    client := f.newHTTPClient()
+
     // Create a new request
-    req, err := http.NewRequest("GET", url, nil)
+    req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
     if err != nil {
-        return nil, err
+        return nil, nil, err
     }
     
     // Make the request
+    start := time.Now()
     httpResp, err := client.Do(req)
     if err != nil {
         // Handle error
-        return nil, err
+        return nil, nil, err
     }
     defer httpResp.Body.Close()
 
+    contentType := httpResp.Header.Get("Content-Type")
+    contentType = strings.ToLower(strings.Split(contentType, ";")[0])
+    response := &ResponseInfo{
+        URL:           url,
+        FinalURL:      httpResp.Request.URL.String(),
+        StatusCode:    httpResp.StatusCode,
+        Status:        httpResp.Status,
+        Proto:         httpResp.Proto,
+        Header:        httpResp.Header,
+        ContentType:   contentType,
+        ContentLength: httpResp.ContentLength,
+        Duration:      time.Since(start),
+        FetchedAt:     start,
+    }
+
     if httpResp.StatusCode != http.StatusOK {
         cacheErr := fmt.Errorf("retrieveHTML failed to fetch URL. HTTP Status: %v", httpResp.Status)
-        f.addToCache(url, nil, cacheErr)
-        return nil, cacheErr
+        f.addDocumentToCache(url, nil, response, cacheErr)
+        return nil, response, cacheErr
     }
 
     // if the content type is not text/html, return an error
-    contentType := httpResp.Header.Get("Content-Type")
-    contentType = strings.ToLower(strings.Split(contentType, ";")[0])
     if contentType != "text/html" && contentType != "text/html; charset=utf-8" {
         cacheErr := fmt.Errorf("retrieveHTML failed to fetch URL. Content-Type: %v", contentType)
-        f.addToCache(url, nil, cacheErr)
-        return nil, cacheErr
+        f.addDocumentToCache(url, nil, response, cacheErr)
+        return nil, response, cacheErr
     }
 
-    doc, _ := html.Parse(httpResp.Body)
+    body := &countingReader{reader: httpResp.Body}
+    doc, _ := html.Parse(body)
     // * Why we are not expecting an error here?
     // Before passing the body to the "html.Parse" function, we have already checked the HTTP status code and the content type of the response.
     // The "golang.org/x/net/html" package is very forgiving, and won't return any error even if we pass an empty string, so we can safely ignore the error here.
     // * Why we are not using the tokinezer instead in order to avoid the auto-correction of the parser that we do not need?
     // Tokenizing would increase the size of the code and the complexity of the implementation.
 
+    // a body cut short by the cancellation is not cached
+    if ctx.Err() != nil {
+        return nil, response, ctx.Err()
+    }
+
+    // html.Parse reads the body until EOF
+    response.ContentLength = body.count
+    response.Duration = time.Since(start)
+
     // Remove script and style tags
+    document := newDocument(doc, url)
+    document.response = response
+
+    f.addDocumentToCache(url, document, response, nil)
+    return document, response, nil
+}
+
+// countingReader counts the bytes read from the underlying reader
+type countingReader struct {
+    reader io.Reader
+    count  int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+    n, err := r.reader.Read(p)
+    r.count += int64(n)
+    return n, err
+}
+
+// newDocument captures the resources of the parsed HTML and removes its script and style tags
+func newDocument(doc *html.Node, url string) *Document {
+    resources := extractResources(doc, url)
     cleanHtml(doc)
+    return &Document{root: doc, url: url, resources: resources}
+}
 
-    f.addToCache(url, doc, nil)
-    return doc, nil
+// newHTTPClient creates an HTTP client using the timeout and the User-Agent of the Fetcher
+func (f *Fetcher) newHTTPClient() *http.Client {
+    timeout := time.Duration(f.props.Timeout) * time.Millisecond
+
+    if f.props.UserAgent != "" {
+        // Create a custom transport with the User-Agent
+        transport := &http.Transport{
+        }
+        // Create a client with the custom transport and timeout
+        return &http.Client{
+            Timeout: timeout,
+            Transport: &UserAgentTransport{
+                UserAgent: f.props.UserAgent,
+                Transport: transport,
+            },
+        }
+    }
+    // Create a standard client with just the timeout if no User-Agent is specified
+    return &http.Client{
+        Timeout: timeout,
+    }
+}
+
+// maxResourceSize limits the size of non-HTML resources (manifests, images, feeds...) read into memory
+const maxResourceSize = 10 << 20 // 10 MB
+
+// fetchResource fetches a non-HTML resource of at most maxResourceSize and returns its body and headers.
+// Unlike retrieveHTML, the result is not cached and the Content-Type is not checked.
+func fetchResource(url string, f *Fetcher) ([]byte, http.Header, error) {
+    return fetchLimitedResource(url, f, maxResourceSize)
+}
+
+// fetchLimitedResource fetches a non-HTML resource like fetchResource, for resources with their own size limit
+// (e.g. sitemaps). Larger resources are an error rather than being truncated.
+func fetchLimitedResource(url string, f *Fetcher, limit int64) ([]byte, http.Header, error) {
+    req, err := http.NewRequest("GET", url, nil)
+    if err != nil {
+        return nil, nil, err
+    }
+    httpResp, err := f.newHTTPClient().Do(req)
+    if err != nil {
+        return nil, nil, err
+    }
+    defer httpResp.Body.Close()
+
+    if httpResp.StatusCode != http.StatusOK {
+        return nil, nil, fmt.Errorf("fetchResource failed to fetch URL. HTTP Status: %v", httpResp.Status)
+    }
+    body, err := io.ReadAll(io.LimitReader(httpResp.Body, limit+1))
+    if err != nil {
+        return nil, nil, err
+    }
+    if int64(len(body)) > limit {
+        return nil, nil, fmt.Errorf("fetchResource failed to fetch URL. Resource larger than %v bytes", limit)
+    }
+    return body, httpResp.Header, nil
+}
+
+// isReachable checks if the URL responds with a successful status code.
+// HEAD is tried first, falling back to GET for servers which do not support it.
+// data: URLs are always reachable.
+func isReachable(url string, f *Fetcher) bool {
+    if strings.HasPrefix(url, "data:") {
+        return true
+    }
+    client := f.newHTTPClient()
+    for _, method := range []string{"HEAD", "GET"} {
+        req, err := http.NewRequest(method, url, nil)
+        if err != nil {
+            return false
+        }
+        httpResp, err := client.Do(req)
+        if err != nil {
+            continue
+        }
+        httpResp.Body.Close()
+        if httpResp.StatusCode == http.StatusMethodNotAllowed || httpResp.StatusCode == http.StatusNotImplemented {
+            continue
+        }
+        return httpResp.StatusCode >= 200 && httpResp.StatusCode < 300
+    }
+    return false
 }
 
 // cleanHtml removes script and style tags from the HTML
@@ -120,8 +246,8 @@ func extractAttributes(attrs []html.Attribute) map[string]string {
     return attrMap
 }
 
-// contains checks if a string is in a slice
-func contains(slice []string, value string) bool {
+// contains checks if a value is in a slice
+func contains[T comparable](slice []T, value T) bool {
     for _, item := range slice {
         if item == value {
             return true
@@ -154,6 +280,28 @@ func ensureAbsoluteURL(href, baseURL string) string {
 }
 
 
+// normalizeURL returns a normalized form of the URL used to compare URLs: lowercase scheme and host,
+// no default port, no fragment, no trailing slash and sorted query parameters.
+// URLs which cannot be parsed are returned as is.
+func normalizeURL(rawURL string) string {
+    uri, err := Url.Parse(rawURL)
+    if err != nil {
+        return rawURL
+    }
+    uri.Scheme = strings.ToLower(uri.Scheme)
+    uri.Host = strings.ToLower(uri.Host)
+    if (uri.Scheme == "http" && strings.HasSuffix(uri.Host, ":80")) || (uri.Scheme == "https" && strings.HasSuffix(uri.Host, ":443")) {
+        uri.Host = uri.Host[:strings.LastIndex(uri.Host, ":")]
+    }
+    uri.Fragment = ""
+    uri.RawFragment = ""
+    uri.Path = strings.TrimSuffix(uri.Path, "/")
+    uri.RawPath = strings.TrimSuffix(uri.RawPath, "/")
+    // Encode sorts the query parameters by key
+    uri.RawQuery = uri.Query().Encode()
+    return uri.String()
+}
+
 func extractDomainParts(rawURL string) (*DomainParts, error) {
     dp := &DomainParts{}
 
@@ -186,4 +334,3 @@ func extractDomainParts(rawURL string) (*DomainParts, error) {
 
     return dp, nil
 }
-
@@ -229,4 +229,23 @@ func TestExtractDomainParts(t *testing.T) {
             }
         })
     }
-}
\ No newline at end of file
+}
+
+func TestNormalizeURL(t *testing.T) {
+    tests := []struct {
+        rawURL   string
+        expected string
+    }{
+        {"HTTP://Example.COM:80/path/", "http://example.com/path"},
+        {"https://example.com:443/", "https://example.com"},
+        {"https://example.com:8443/a", "https://example.com:8443/a"},
+        {"http://example.com/page#section", "http://example.com/page"},
+        {"http://example.com/search?b=2&a=1", "http://example.com/search?a=1&b=2"},
+        {"http://%gh&%$", "http://%gh&%$"},
+    }
+    for _, tt := range tests {
+        if result := normalizeURL(tt.rawURL); result != tt.expected {
+            t.Errorf("normalizeURL(%q) = %q; want %q", tt.rawURL, result, tt.expected)
+        }
+    }
+}